@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/koki-develop/mingo/minify"
+)
+
+// result is the minified form of a single file.
+type result struct {
+	path      string
+	src       []byte
+	min       []byte
+	sourceMap *minify.SourceMap
+	renames   []minify.Rename
+	// skipped is set for files left unchanged, which are copied to --out-dir as they are.
+	skipped bool
+}
+
+// job minifies a single file, or every file of a package with --rename-globals.
+type job struct {
+	// files is the number of files the job processes, used to count failures.
+	files int
+	run   func() ([]result, error)
+}
+
+// runJobs runs jobs on up to n goroutines and calls done with the outcome of
+// each job in the order of jobs, as soon as that job and all jobs before it
+// have finished. done is always called on the calling goroutine.
+func runJobs(jobs []job, n int, done func(job, []result, error)) {
+	type outcome struct {
+		results []result
+		err     error
+	}
+	outcomes := make([]outcome, len(jobs))
+	finished := make([]chan struct{}, len(jobs))
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	// jobs are started in order, so that the output is not held back
+	// by a job that was started late
+	next := make(chan int)
+	go func() {
+		for i := range jobs {
+			next <- i
+		}
+		close(next)
+	}()
+
+	var wg sync.WaitGroup
+	for range min(n, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results, err := jobs[i].run()
+				outcomes[i] = outcome{results: results, err: err}
+				close(finished[i])
+			}
+		}()
+	}
+
+	for i, j := range jobs {
+		<-finished[i]
+		done(j, outcomes[i].results, outcomes[i].err)
+		// release the results once they are written
+		outcomes[i] = outcome{}
+	}
+	wg.Wait()
+}
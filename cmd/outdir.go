@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/koki-develop/mingo/minify"
+)
+
+// outPath returns the path in --out-dir that mirrors path.
+// Paths are mirrored relative to the current directory.
+func outPath(path string) (string, error) {
+	rel := path
+	if filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		if rel, err = filepath.Rel(wd, path); err != nil {
+			return "", err
+		}
+	}
+
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s: cannot mirror a file outside of the current directory into --out-dir", path)
+	}
+	return filepath.Join(flagOutDir, rel), nil
+}
+
+// isOutDir reports whether dir is --out-dir, so that previous results are not minified again.
+func isOutDir(dir string) bool {
+	a, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	b, err := filepath.Abs(flagOutDir)
+	if err != nil {
+		return false
+	}
+	return a == b
+}
+
+// minifierFor returns the Minifier for the file at path, which is mn unless
+// the file is mirrored in --out-dir with --line-directives: the directives
+// then name the original file by its path relative to the mirrored one.
+func minifierFor(mn *minify.Minifier, opts minify.Options, path string) *minify.Minifier {
+	if flagOutDir == "" || !opts.LineDirectives {
+		return mn
+	}
+	dst, err := outPath(path)
+	if err != nil {
+		// reported when writing the file
+		return mn
+	}
+	opts.LineDirectivesDir = filepath.Dir(dst)
+	return minify.New(opts)
+}
+
+// writeOutDir writes the minified source of path, and its source map if any, to --out-dir.
+func writeOutDir(path string, min []byte, sm *minify.SourceMap) error {
+	dst, err := outPath(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, min, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := writeSourceMap(dst, path, sm); err != nil {
+		return err
+	}
+	fmt.Println(dst)
+
+	return nil
+}
+
+// copyFile copies a file to --out-dir unchanged, such as a non-Go file or
+// a file left unchanged by --tests=skip or --skip-generated.
+func copyFile(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	dst, err := outPath(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, src, info.Mode().Perm())
+}
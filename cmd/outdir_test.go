@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outDir_skipped(t *testing.T) {
+	files := map[string]string{
+		"main.go":      "package main\n\nfunc main() {\n\tprintln(1)\n}\n",
+		"main_test.go": "package main\n\nimport \"testing\"\n\nfunc TestMain(t *testing.T) {\n\tmain()\n}\n",
+		"gen.go":       "// Code generated by hand. DO NOT EDIT.\n\npackage main\n\nfunc gen() int {\n\treturn 1\n}\n",
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "tests skip",
+			args: []string{"--tests=skip"},
+			want: map[string]string{
+				"main.go":      "package main;func main(){println(1)};",
+				"main_test.go": files["main_test.go"],
+			},
+		},
+		{
+			name: "rename globals",
+			args: []string{"--tests=skip", "--rename-globals"},
+			want: map[string]string{
+				"main.go":      "package main;func main(){println(1)};",
+				"main_test.go": files["main_test.go"],
+			},
+		},
+		{
+			name: "skip generated",
+			args: []string{"--skip-generated"},
+			want: map[string]string{
+				"main.go": "package main;func main(){println(1)};",
+				"gen.go":  files["gen.go"],
+			},
+		},
+		{
+			name: "skip generated with rename globals",
+			args: []string{"--skip-generated", "--rename-globals"},
+			want: map[string]string{
+				"main.go": "package main;func main(){println(1)};",
+				"gen.go":  files["gen.go"],
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			}
+			chdir(t, dir)
+			resetFlags(t)
+
+			rootCmd.SetArgs(append([]string{"-o", "out", "."}, tt.args...))
+			require.NoError(t, rootCmd.Execute())
+
+			for name, want := range tt.want {
+				got, err := os.ReadFile(filepath.Join("out", name))
+				require.NoError(t, err)
+				assert.Equal(t, want, string(got), name)
+			}
+		})
+	}
+}
+
+// chdir changes the current directory to dir until the end of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+}
+
+// resetFlags restores the default values of the flags at the end of the test.
+func resetFlags(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		flagOutDir, flagTests, flagSkipGenerated, flagRenameGlobals, flagRenameMap = "", "minify", false, false, "renames.json"
+		flagCheck = false
+		rootCmd.Flags().Lookup("rename-map").Changed = false
+	})
+}
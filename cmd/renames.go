@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koki-develop/mingo/minify"
+)
+
+// writesRenameMap reports whether the renamed identifiers are written to --rename-map.
+func writesRenameMap() bool {
+	return (flagRenameLocals || flagRenameGlobals) && (flagWrite || flagOutDir != "") && flagRenameMap != ""
+}
+
+// renamesIn returns the renames of the identifiers declared in the file at path.
+func renamesIn(renames []minify.Rename, path string) []minify.Rename {
+	var in []minify.Rename
+	for _, r := range renames {
+		if strings.HasPrefix(r.Pos, path+":") {
+			in = append(in, r)
+		}
+	}
+	return in
+}
+
+// writeRenameMap writes renames to path as JSON, one field per line
+// so that the maps of different releases can be diffed.
+func writeRenameMap(path string, renames []minify.Rename) error {
+	if renames == nil {
+		renames = []minify.Rename{}
+	}
+	b, err := json.MarshalIndent(renames, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeAtomic(path, append(b, '\n'), nil)
+}
+
+// readRenameMap reads a file written by --rename-map.
+func readRenameMap(path string) ([]minify.Rename, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var renames []minify.Rename
+	if err := json.Unmarshal(b, &renames); err != nil {
+		return nil, fmt.Errorf("%s: invalid rename map: %w", path, err)
+	}
+	return renames, nil
+}
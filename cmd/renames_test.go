@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renameMap(t *testing.T) {
+	src := "package main\n\nfunc greet(name string) {\n\tprintln(name)\n}\n\nfunc main() {\n\tgreet(\"gopher\")\n}\n"
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "in out dir by default",
+			args: []string{"-o", "out", "--rename-globals", "."},
+			want: "out/renames.json",
+		},
+		{
+			name: "given path",
+			args: []string{"-o", "out", "--rename-globals", "--rename-map", "map.json", "."},
+			want: "map.json",
+		},
+		{
+			name: "disabled",
+			args: []string{"-o", "out", "--rename-globals", "--rename-map", "", "."},
+		},
+		{
+			name: "disabled without renaming",
+			args: []string{"-o", "out", "--rename-map", "", "."},
+		},
+		{
+			name:    "without renaming",
+			args:    []string{"-o", "out", "--rename-map", "map.json", "."},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644))
+			chdir(t, dir)
+			resetFlags(t)
+
+			rootCmd.SetArgs(tt.args)
+			err := rootCmd.Execute()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, path := range []string{"renames.json", "map.json", "out/renames.json"} {
+				if path == tt.want {
+					assert.FileExists(t, path)
+				} else {
+					assert.NoFileExists(t, path)
+				}
+			}
+		})
+	}
+}
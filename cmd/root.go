@@ -3,77 +3,418 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 
-	"github.com/koki-develop/mingo/internal/mingo"
+	"github.com/koki-develop/mingo/internal/diff"
+	"github.com/koki-develop/mingo/minify"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagWrite bool
+	flagWrite          bool
+	flagRenameLocals   bool
+	flagRenameGlobals  bool
+	flagVerify         bool
+	flagFilename       string
+	flagOutDir         string
+	flagCopy           bool
+	flagList           bool
+	flagCheck          bool
+	flagDiff           bool
+	flagDiffGofmt      bool
+	flagJobs           int
+	flagBackup         string
+	flagExcludes       []string
+	flagIncludes       []string
+	flagTests          string
+	flagSkipGenerated  bool
+	flagKeepHeader     bool
+	flagKeepComments   []string
+	flagMinifyCgo      bool
+	flagLineDirectives bool
+	flagSourceMap      bool
+	flagRenameMap      string
+	flagRenameMapIn    string
+	flagRemoveImports  bool
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "mingo [flags] [files]...",
 	Short: "Go language also wants to be minified",
-	Long:  "Go language also wants to be minified.",
-	Args:  cobra.MinimumNArgs(1),
+	Long:  "Go language also wants to be minified.\n\nWith no files, or with \"-\", mingo reads from standard input and writes to standard output.",
+	Args:  cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		for _, file := range args {
-			err := filepath.WalkDir(file, func(path string, d os.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
+		var tests minify.TestsMode
+		if err := tests.UnmarshalText([]byte(flagTests)); err != nil {
+			return err
+		}
+		var keepComments []*regexp.Regexp
+		for _, expr := range flagKeepComments {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("invalid --keep-comment: %w", err)
+			}
+			keepComments = append(keepComments, re)
+		}
 
-				if d.IsDir() {
-					return nil
-				}
+		var renameMap []minify.Rename
+		if flagRenameMapIn != "" {
+			if !flagRenameLocals && !flagRenameGlobals {
+				return fmt.Errorf("cannot use --rename-map-in without --rename-locals or --rename-globals")
+			}
+			m, err := readRenameMap(flagRenameMapIn)
+			if err != nil {
+				return err
+			}
+			renameMap = m
+		}
 
-				if filepath.Ext(path) != ".go" {
-					return nil
-				}
+		opts := minify.Options{
+			RenameLocals:        flagRenameLocals,
+			RenameGlobals:       flagRenameGlobals,
+			Verify:              flagVerify,
+			Tests:               tests,
+			SkipGenerated:       flagSkipGenerated,
+			KeepHeader:          flagKeepHeader,
+			MinifyCgoPreamble:   flagMinifyCgo,
+			LineDirectives:      flagLineDirectives,
+			KeepComments:        keepComments,
+			RenameMap:           renameMap,
+			RemoveUnusedImports: flagRemoveImports,
+			SourceMaps:          flagSourceMap,
+		}
+		mn := minify.New(opts)
 
-				src, err := os.ReadFile(path)
-				if err != nil {
-					return err
-				}
+		if flagDiffGofmt && !flagDiff {
+			return fmt.Errorf("cannot use --diff-gofmt without --diff")
+		}
+		if flagSourceMap && !flagWrite && flagOutDir == "" {
+			return fmt.Errorf("cannot use --source-map without --write or --out-dir")
+		}
+		if flagRenameMap != "" && cmd.Flags().Changed("rename-map") && !flagRenameLocals && !flagRenameGlobals {
+			return fmt.Errorf("cannot use --rename-map without --rename-locals or --rename-globals")
+		}
+		// by default, the rename map is written with the results
+		renameMapPath := flagRenameMap
+		if flagOutDir != "" && !cmd.Flags().Changed("rename-map") {
+			renameMapPath = filepath.Join(flagOutDir, flagRenameMap)
+		}
+		if flagBackup != "" && !flagWrite {
+			return fmt.Errorf("cannot use --backup without --write")
+		}
+		if flagSourceMap && flagWrite && flagBackup == "" {
+			// the source map would refer to the overwritten file
+			return fmt.Errorf("cannot use --source-map with --write without --backup")
+		}
 
-				min, err := mingo.Minify(path, src)
+		if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+			if len(args) == 0 && isTerminal(os.Stdin) {
+				return fmt.Errorf("no input files")
+			}
+			if flagWrite || flagOutDir != "" {
+				return fmt.Errorf("cannot use --write or --out-dir with standard input")
+			}
+			cmd.SilenceUsage = true
+			return minifyStdin(mn)
+		}
+
+		if flagWrite && flagOutDir != "" {
+			return fmt.Errorf("cannot use --write with --out-dir")
+		}
+		if (flagList || flagCheck || flagDiff) && (flagWrite || flagOutDir != "") {
+			return fmt.Errorf("cannot use --list, --check or --diff with --write or --out-dir")
+		}
+		if flagJobs < 0 {
+			return fmt.Errorf("--jobs must not be negative")
+		}
+		if flagCopy && flagOutDir == "" {
+			return fmt.Errorf("cannot use --copy without --out-dir")
+		}
+
+		paths, others, err := walk(args)
+		if err != nil {
+			return err
+		}
+
+		var jobs []job
+		if flagRenameGlobals {
+			for _, pkg := range groupByDir(paths) {
+				jobs = append(jobs, job{files: len(pkg), run: func() ([]result, error) {
+					return minifyPackage(minifierFor(mn, opts, pkg[0]), pkg)
+				}})
+			}
+		} else {
+			for _, path := range paths {
+				jobs = append(jobs, job{files: 1, run: func() ([]result, error) {
+					return minifyFile(minifierFor(mn, opts, path), path)
+				}})
+			}
+		}
+
+		// errors in a single file are reported after the remaining files are processed
+		cmd.SilenceUsage = true
+		failed, unminified := 0, 0
+		var errs []error
+		var renames []minify.Rename
+		n := flagJobs
+		if n == 0 {
+			n = runtime.NumCPU()
+		}
+		runJobs(jobs, n, func(j job, results []result, err error) {
+			for _, res := range results {
 				if err != nil {
-					return err
+					break
 				}
-
-				if flagWrite {
-					if bytes.Equal(src, min) {
-						return nil
-					}
-
-					f, err := os.Create(path)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-
-					if _, err := f.Write(min); err != nil {
-						return err
-					}
-					fmt.Println(path)
-				} else {
-					fmt.Println(string(min))
+				if res.skipped {
+					err = copyFile(res.path)
+					continue
 				}
-
-				return nil
-			})
+				var differs bool
+				differs, err = output(res.path, res.src, res.min, res.sourceMap)
+				if differs {
+					unminified++
+				}
+			}
 			if err != nil {
-				return err
+				errs = append(errs, err)
+				failed += j.files
+				return
+			}
+			for _, res := range results {
+				renames = append(renames, res.renames...)
+			}
+		})
+
+		for _, path := range others {
+			if err := copyFile(path); err != nil {
+				errs = append(errs, err)
+				failed++
+			}
+		}
+
+		if writesRenameMap() {
+			if err := writeRenameMap(renameMapPath, renames); err != nil {
+				errs = append(errs, err)
 			}
 		}
 
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to process %d of %d files", failed, len(paths)+len(others))
+		}
+		if flagCheck && unminified > 0 {
+			return fmt.Errorf("%d of %d files are not minified", unminified, len(paths))
+		}
 		return nil
 	},
 }
 
+func minifyStdin(mn *minify.Minifier) error {
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	min, err := mn.MinifyFile(flagFilename, src)
+	if err != nil {
+		return err
+	}
+
+	// like gofmt -l, standard input is listed as --filename
+	differs, err := output(flagFilename, src, min, nil)
+	if err != nil {
+		return err
+	}
+	if flagCheck && differs {
+		return fmt.Errorf("%s is not minified", flagFilename)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or a file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func minifyFile(mn *minify.Minifier, path string) ([]result, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	// files left unchanged are not output, except to mirror them in --out-dir
+	if mn.Skips(fset, file) {
+		if flagOutDir != "" {
+			return []result{{path: path, skipped: true}}, nil
+		}
+		return nil, nil
+	}
+
+	// without --rename-globals, a single file is minified the same way as a package
+	pr, err := mn.MinifyPackageResult(fset, []*ast.File{file})
+	if err != nil {
+		return nil, err
+	}
+
+	res := result{path: path, src: src, min: pr.Files[0]}
+	if pr.SourceMaps != nil {
+		res.sourceMap = pr.SourceMaps[0]
+	}
+	if writesRenameMap() {
+		res.renames = pr.Renames
+	}
+	return []result{res}, nil
+}
+
+// groupByDir groups paths by their directory, keeping the order of paths.
+func groupByDir(paths []string) [][]string {
+	var groups [][]string
+	index := map[string]int{}
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], path)
+	}
+	return groups
+}
+
+// minifyPackage minifies the files in paths, which belong to the same directory,
+// one package at a time.
+func minifyPackage(mn *minify.Minifier, paths []string) ([]result, error) {
+	fset := token.NewFileSet()
+	srcs := map[string][]byte{}
+	pkgs := map[string][]*ast.File{}
+	var names []string
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		srcs[path] = src
+
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pkgs[file.Name.Name]; !ok {
+			names = append(names, file.Name.Name)
+		}
+		pkgs[file.Name.Name] = append(pkgs[file.Name.Name], file)
+	}
+
+	var results []result
+	for _, name := range names {
+		files := pkgs[name]
+		pr, err := mn.MinifyPackageResult(fset, files)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, file := range files {
+			path := fset.Position(file.Pos()).Filename
+			if mn.Skips(fset, file) {
+				// only type-checked with the package
+				if flagOutDir != "" {
+					results = append(results, result{path: path, skipped: true})
+				}
+				continue
+			}
+			res := result{path: path, src: srcs[path], min: pr.Files[i]}
+			if pr.SourceMaps != nil {
+				res.sourceMap = pr.SourceMaps[i]
+			}
+			if writesRenameMap() {
+				res.renames = renamesIn(pr.Renames, path)
+			}
+			results = append(results, res)
+		}
+	}
+
+	return results, nil
+}
+
+// output lists, checks, diffs, writes or prints the minified source min of
+// the file at path. With --list, --check or --diff, it reports whether min
+// differs from the content src of the file.
+func output(path string, src, min []byte, sm *minify.SourceMap) (differs bool, err error) {
+	if flagList || flagCheck || flagDiff {
+		if bytes.Equal(src, min) {
+			return false, nil
+		}
+		if flagList {
+			fmt.Println(path)
+		}
+		if flagDiff {
+			return true, printDiff(path, src, min)
+		}
+		return true, nil
+	}
+
+	if flagOutDir != "" {
+		return false, writeOutDir(path, min, sm)
+	}
+
+	if !flagWrite {
+		fmt.Println(string(min))
+		return false, nil
+	}
+
+	if bytes.Equal(src, min) {
+		return false, nil
+	}
+
+	if err := writeFile(path, src, min); err != nil {
+		return false, err
+	}
+	if err := writeSourceMap(path, realPath(path)+flagBackup, sm); err != nil {
+		return false, err
+	}
+	fmt.Println(path)
+
+	return false, nil
+}
+
+// printDiff prints a unified diff from src to its minified form min.
+// With --diff-gofmt, min is formatted first so that the diff shows
+// which declarations and statements changed rather than a single long line.
+func printDiff(path string, src, min []byte) error {
+	if flagDiffGofmt {
+		formatted, err := format.Source(min)
+		if err != nil {
+			return err
+		}
+		min = formatted
+	}
+
+	_, err := os.Stdout.Write(diff.Unified(path+".orig", src, path, min))
+	return err
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -82,4 +423,28 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolVarP(&flagWrite, "write", "w", false, "write result to (source) file instead of stdout")
+	rootCmd.Flags().StringVar(&flagBackup, "backup", "", "with --write, keep the original of each rewritten file with this suffix (e.g. .orig)")
+	rootCmd.Flags().BoolVar(&flagRenameLocals, "rename-locals", false, "rename local variables, parameters and labels to short names")
+	rootCmd.Flags().BoolVar(&flagRenameGlobals, "rename-globals", false, "rename unexported package-level identifiers across each package (all files of a package must be given)")
+	rootCmd.Flags().StringVar(&flagRenameMap, "rename-map", "renames.json", "with --write or --out-dir, write the renamed identifiers as JSON to this file, in --out-dir by default (empty to disable)")
+	rootCmd.Flags().StringVar(&flagRenameMapIn, "rename-map-in", "", "keep the names of the identifiers renamed in a previous run, from a file written by --rename-map")
+	rootCmd.Flags().BoolVar(&flagRemoveImports, "remove-unused-imports", false, "drop imports whose package is not referred to, except blank and dot imports, to clean up input that does not compile")
+	rootCmd.Flags().BoolVarP(&flagList, "list", "l", false, "list files whose minified form differs from their content")
+	rootCmd.Flags().BoolVar(&flagCheck, "check", false, "exit with a non-zero status if any file is not minified")
+	rootCmd.Flags().BoolVarP(&flagDiff, "diff", "d", false, "display diffs instead of rewriting files")
+	rootCmd.Flags().BoolVar(&flagDiffGofmt, "diff-gofmt", false, "format the minified source with gofmt before diffing, for readability")
+	rootCmd.Flags().StringVarP(&flagOutDir, "out-dir", "o", "", "write results to a directory mirroring the layout of the input files")
+	rootCmd.Flags().BoolVar(&flagCopy, "copy", false, "copy non-Go files to --out-dir as well")
+	rootCmd.Flags().StringArrayVar(&flagExcludes, "exclude", nil, "skip files and directories matching a pattern in .mingoignore syntax, relative to each argument (can be repeated)")
+	rootCmd.Flags().StringArrayVar(&flagIncludes, "include", nil, "only process files matching a pattern in .mingoignore syntax, relative to each argument (can be repeated)")
+	rootCmd.Flags().StringVar(&flagFilename, "filename", "<standard input>", "file name used in error messages when reading from standard input")
+	rootCmd.Flags().IntVarP(&flagJobs, "jobs", "j", 0, "number of files to minify in parallel (0 means the number of CPUs)")
+	rootCmd.Flags().StringVar(&flagTests, "tests", "minify", "how to treat _test.go files: minify, skip, or preserve-examples to keep the output comments of examples")
+	rootCmd.Flags().BoolVar(&flagSkipGenerated, "skip-generated", false, "leave files with a \"Code generated ... DO NOT EDIT.\" comment unchanged")
+	rootCmd.Flags().BoolVar(&flagKeepHeader, "keep-header", false, "keep the first comment of each file, such as a license header, if it comes before the package clause")
+	rootCmd.Flags().StringArrayVar(&flagKeepComments, "keep-comment", nil, "keep comments before the package clause that match a regular expression (can be repeated)")
+	rootCmd.Flags().BoolVar(&flagMinifyCgo, "minify-cgo", false, "remove the indentation and blank lines of the C code in cgo preambles")
+	rootCmd.Flags().BoolVar(&flagLineDirectives, "line-directives", false, "emit /*line*/ directives so that compiler errors and panics refer to the original source")
+	rootCmd.Flags().BoolVar(&flagSourceMap, "source-map", false, "write a source map (version 3) next to each written file, with the .map extension (with --write, requires --backup)")
+	rootCmd.Flags().BoolVar(&flagVerify, "verify", false, "fail files whose minified form changes the exported API")
 }
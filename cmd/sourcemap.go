@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/koki-develop/mingo/minify"
+)
+
+// writeSourceMap writes sm to dst.map, where dst is the minified file,
+// referring to the original file at source.
+func writeSourceMap(dst, source string, sm *minify.SourceMap) error {
+	if sm == nil {
+		return nil
+	}
+
+	rel, err := relPath(filepath.Dir(dst), source)
+	if err != nil {
+		return err
+	}
+	sm.File = filepath.Base(dst)
+	sm.Sources = []string{filepath.ToSlash(rel)}
+
+	b, err := json.Marshal(sm)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(dst+".map", b, nil)
+}
+
+// relPath returns the path of target relative to the directory dir.
+func relPath(dir, target string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(dir, target)
+}
@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/koki-develop/mingo/internal/astutil"
+	"github.com/koki-develop/mingo/minify"
+	"github.com/spf13/cobra"
+)
+
+var errNoMaps = errors.New("no source maps found")
+
+var unmapCmd = &cobra.Command{
+	Use:   "unmap [flags] maps...",
+	Short: "Rewrite a stack trace of a minified program to refer to the original source",
+	Long: "Rewrite a stack trace of a minified program, such as a panic or a goroutine dump, read from standard input, to refer to the original source.\n\n" +
+		"Each argument is a source map written by --source-map, or a directory to search for them. The minified file must be next to its source map.\n\n" +
+		"Stack traces do not include columns, so unless the files were minified with --line-directives, a frame in a line holding several statements refers to the first of them in its function.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		u := &unmapper{names: map[string]map[string]string{}}
+		for _, arg := range args {
+			if err := u.load(arg); err != nil {
+				return err
+			}
+		}
+
+		if len(u.files) == 0 {
+			return errNoMaps
+		}
+
+		cmd.SilenceUsage = true
+		trace, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(u.rewrite(trace))
+		return err
+	},
+}
+
+// mappedFile is a minified file described by a source map.
+type mappedFile struct {
+	path string
+	// source is the path of the original file, relative to the directory of path.
+	source string
+	// lineDirectives is set if the file has line directives, and lineFile
+	// is the file name of the first of them, relative to the directory of path.
+	lineDirectives bool
+	lineFile       string
+	mappings       []minify.Mapping
+
+	fset *token.FileSet
+	file *ast.File
+}
+
+// unmapper rewrites the frames of stack traces in minified files.
+type unmapper struct {
+	files []*mappedFile
+
+	// names maps the minified names of the package-level functions and
+	// types, and of methods as "T.m", to their original names, by the
+	// directory of each package.
+	names map[string]map[string]string
+}
+
+// load loads the source maps at path, which may be a directory.
+func (u *unmapper) load(path string) error {
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (p != path && !strings.HasSuffix(p, ".go.map")) {
+			return nil
+		}
+		return u.loadMap(p)
+	})
+}
+
+func (u *unmapper) loadMap(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var sm minify.SourceMap
+	if err := json.Unmarshal(b, &sm); err != nil || sm.Version != 3 || sm.File == "" || len(sm.Sources) != 1 {
+		return fmt.Errorf("%s: not a source map written by mingo", path)
+	}
+	mappings, err := sm.Decode()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	min, err := filepath.Abs(filepath.Join(filepath.Dir(path), sm.File))
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, min, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	f := &mappedFile{
+		path:     min,
+		source:   sm.Sources[0],
+		mappings: mappings,
+		fset:     fset,
+		file:     file,
+	}
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "/*line ") || strings.HasPrefix(c.Text, "//line ") {
+				if !f.lineDirectives {
+					f.lineFile = directiveFile(c.Text)
+				}
+				f.lineDirectives = true
+			}
+		}
+	}
+	u.files = append(u.files, f)
+	u.addNames(f)
+	return nil
+}
+
+// directiveFile returns the file name of a line directive, such as
+// "/*line main.go:1:1*/".
+func directiveFile(text string) string {
+	text = strings.TrimSuffix(strings.TrimPrefix(text[2:], "line "), "*/")
+	for i := 0; i < 2; i++ {
+		if j := strings.LastIndexByte(text, ':'); j >= 0 {
+			if _, err := strconv.Atoi(text[j+1:]); err == nil {
+				text = text[:j]
+			}
+		}
+	}
+	return text
+}
+
+// addNames records the original names of the renamed functions, methods
+// and types declared in f.
+func (u *unmapper) addNames(f *mappedFile) {
+	renamed := map[[2]int]string{}
+	for _, mp := range f.mappings {
+		if mp.Name != "" {
+			renamed[[2]int{mp.Line, mp.Column}] = mp.Name
+		}
+	}
+	original := func(id *ast.Ident) (string, bool) {
+		pos := f.fset.PositionFor(id.Pos(), false)
+		name, ok := renamed[[2]int{pos.Line - 1, pos.Column - 1}]
+		return name, ok
+	}
+
+	dir := filepath.Dir(f.path)
+	names := u.names[dir]
+	if names == nil {
+		names = map[string]string{}
+		u.names[dir] = names
+	}
+	for _, decl := range f.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name, ok := original(decl.Name)
+			if !ok {
+				continue
+			}
+			if decl.Recv != nil {
+				names[astutil.RecvTypeName(decl)+"."+decl.Name.Name] = name
+			} else {
+				names[decl.Name.Name] = name
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					if name, ok := original(spec.Name); ok {
+						names[spec.Name.Name] = name
+					}
+				}
+			}
+		}
+	}
+}
+
+// lookup returns the minified file that the path of a frame of the package
+// pkg refers to, which is the one sharing the most trailing path elements
+// with it, or with the file named by its line directives, as the program may have been built on another machine or with
+// -trimpath. A file sharing only its name must belong to pkg, so that frames
+// of other modules are left unchanged.
+func (u *unmapper) lookup(p, pkg string) *mappedFile {
+	elems := strings.Split(p, "/")
+	var best *mappedFile
+	bestScore, ties := 0, 0
+	for _, f := range u.files {
+		if !f.inPackage(pkg) {
+			continue
+		}
+		fElems := strings.Split(filepath.ToSlash(f.framePath()), "/")
+		score := 0
+		for score < len(elems) && score < len(fElems) && elems[len(elems)-1-score] == fElems[len(fElems)-1-score] {
+			score++
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, ties = f, score, 0
+		case score == bestScore:
+			ties++
+		}
+	}
+	if bestScore == 0 || ties > 0 {
+		return nil
+	}
+	return best
+}
+
+// framePath returns the path of f as frames report it: that of the file
+// named by its line directives, if any.
+func (f *mappedFile) framePath() string {
+	if f.lineDirectives && f.lineFile != "" {
+		return filepath.Join(filepath.Dir(f.path), filepath.FromSlash(f.lineFile))
+	}
+	return f.path
+}
+
+// inPackage reports whether f may belong to the package with the path pkg,
+// which is the case if the last element of pkg is the name of the package
+// of f or the name of its directory.
+func (f *mappedFile) inPackage(pkg string) bool {
+	name := path.Base(pkg)
+	return name == f.file.Name.Name || name == filepath.Base(filepath.Dir(f.path))
+}
+
+// framePos matches the position of a frame, such as
+// "\t/path/to/main.go:1 +0x1d", which follows the function of the frame.
+var framePos = regexp.MustCompile(`^(\t)(.+):(\d+)(\s.*)?$`)
+
+// rewrite rewrites the functions and positions of the frames of trace in
+// minified files, leaving the rest unchanged.
+func (u *unmapper) rewrite(trace []byte) []byte {
+	lines := strings.Split(string(trace), "\n")
+	for i := 1; i < len(lines); i++ {
+		m := framePos.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		prefix, sym, suffix := splitFrameFunc(lines[i-1])
+		pkg, elems := splitSymbol(sym)
+		f := u.lookup(m[2], pkg)
+		if f == nil {
+			continue
+		}
+		if f.lineDirectives {
+			// the line is already the original one
+			lines[i] = m[1] + f.sourcePath(m[2]) + ":" + m[3] + m[4]
+		} else {
+			line, _ := strconv.Atoi(m[3])
+			if srcLine, ok := f.sourceLine(line, elems); ok {
+				lines[i] = m[1] + f.sourcePath(m[2]) + ":" + strconv.Itoa(srcLine) + m[4]
+			}
+		}
+		if len(elems) > 0 {
+			u.renameSymbol(filepath.Dir(f.path), elems)
+			lines[i-1] = prefix + pkg + "." + strings.Join(elems, ".") + suffix
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// splitFrameFunc splits the function line of a frame, such as
+// "main.(*a).b(0x1, ...)" or "created by main.c in goroutine 1",
+// around the symbol of the function.
+func splitFrameFunc(line string) (prefix, sym, suffix string) {
+	if rest, ok := strings.CutPrefix(line, "created by "); ok {
+		sym, suffix = rest, ""
+		if i := strings.Index(rest, " in goroutine "); i >= 0 {
+			sym, suffix = rest[:i], rest[i:]
+		}
+		return "created by ", sym, suffix
+	}
+
+	// the arguments start at the first parenthesis not opening a receiver, as in ".(*T)"
+	for i := 1; i < len(line); i++ {
+		if line[i] == '(' && line[i-1] != '.' {
+			return "", line[:i], line[i:]
+		}
+	}
+	return "", line, ""
+}
+
+// splitSymbol splits a symbol, such as "example.com/pkg.(*a).b.func1",
+// into its package path and the elements of its name.
+func splitSymbol(sym string) (pkg string, elems []string) {
+	slash := strings.LastIndex(sym, "/")
+	dot := strings.Index(sym[slash+1:], ".")
+	if dot < 0 {
+		return sym, nil
+	}
+	dot += slash + 1
+
+	// type parameters are printed as "[...]"
+	depth, start := 0, dot+1
+	for i := start; i < len(sym); i++ {
+		switch sym[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				elems = append(elems, sym[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return sym[:dot], append(elems, sym[start:])
+}
+
+// splitElem splits an element of a symbol, such as "(*a)" or "b[...]",
+// around its identifier.
+func splitElem(elem string) (prefix, id, suffix string) {
+	if rest, ok := strings.CutPrefix(elem, "(*"); ok {
+		prefix, elem, suffix = "(*", strings.TrimSuffix(rest, ")"), ")"
+	}
+	if i := strings.IndexByte(elem, '['); i >= 0 {
+		elem, suffix = elem[:i], elem[i:]+suffix
+	}
+	return prefix, elem, suffix
+}
+
+// renameSymbol restores the original names in the elements of a symbol of
+// the package in dir: a function or a method, followed by its closures.
+func (u *unmapper) renameSymbol(dir string, elems []string) {
+	names := u.names[dir]
+	rename := func(i int, key string) {
+		if name, ok := names[key]; ok {
+			prefix, _, suffix := splitElem(elems[i])
+			elems[i] = prefix + name + suffix
+		}
+	}
+
+	_, recv, _ := splitElem(elems[0])
+	if len(elems) > 1 {
+		_, method, _ := splitElem(elems[1])
+		rename(1, recv+"."+method)
+	}
+	rename(0, recv)
+}
+
+// sourcePath returns the path of the original file of a frame at the path p
+// in f. With line directives, frames name the file of the directives, which
+// is the original file unless they name the minified file itself, as with
+// --write.
+func (f *mappedFile) sourcePath(p string) string {
+	if f.lineDirectives && (f.lineFile == f.source || path.Dir(f.lineFile) != ".") {
+		return p
+	}
+	return path.Join(path.Dir(p), f.source)
+}
+
+// sourceLine returns the original line of the 1-based line of f in the
+// function named by the elements of a symbol. Without a column, the
+// position of the first token of the function in that line is used.
+func (f *mappedFile) sourceLine(line int, elems []string) (int, bool) {
+	tf := f.fset.File(f.file.Pos())
+	if line < 1 || line > tf.LineCount() {
+		return 0, false
+	}
+	start, end := token.NoPos, token.NoPos
+	if fn := f.funcDecl(elems); fn != nil {
+		start, end = fn.Pos(), fn.End()
+	}
+
+	for _, mp := range f.mappings {
+		if mp.Line != line-1 {
+			continue
+		}
+		if start.IsValid() {
+			pos := tf.LineStart(line) + token.Pos(mp.Column)
+			if pos < start || pos >= end {
+				continue
+			}
+		}
+		return mp.SourceLine + 1, true
+	}
+	return 0, false
+}
+
+// funcDecl returns the declaration of the function or method named by the
+// elements of a symbol, if any.
+func (f *mappedFile) funcDecl(elems []string) *ast.FuncDecl {
+	if len(elems) == 0 {
+		return nil
+	}
+	_, first, _ := splitElem(elems[0])
+	second := ""
+	if len(elems) > 1 {
+		_, second, _ = splitElem(elems[1])
+	}
+
+	var fn *ast.FuncDecl
+	for _, decl := range f.file.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if decl.Recv != nil && astutil.RecvTypeName(decl) == first && decl.Name.Name == second {
+			return decl
+		}
+		if decl.Recv == nil && decl.Name.Name == first && fn == nil {
+			fn = decl
+		}
+	}
+	return fn
+}
+
+func init() {
+	rootCmd.AddCommand(unmapCmd)
+}
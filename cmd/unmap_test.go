@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koki-develop/mingo/minify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unmapSrc = `package main
+
+type server struct{ n int }
+
+func (s *server) handle(n int) int {
+	if n > 0 {
+		panic("boom")
+	}
+	return n
+}
+
+func run() {
+	s := &server{}
+	go func() {
+		s.handle(1)
+	}()
+}
+
+func main() {
+	run()
+}
+`
+
+// newUnmapper minifies unmapSrc with opts into dir/main.go with a source map
+// referring to dir/main.go.orig, and loads it. The source is parsed as the
+// file name in dir, which line directives name.
+func newUnmapper(t *testing.T, dir string, opts minify.Options, name string) *unmapper {
+	t.Helper()
+	orig := filepath.Join(dir, "main.go.orig")
+	require.NoError(t, os.WriteFile(orig, []byte(unmapSrc), 0o644))
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(dir, name), unmapSrc, parser.ParseComments)
+	require.NoError(t, err)
+	opts.RenameGlobals, opts.SourceMaps = true, true
+	res, err := minify.New(opts).MinifyPackageResult(fset, []*ast.File{file})
+	require.NoError(t, err)
+
+	dst := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(dst, res.Files[0], 0o644))
+	require.NoError(t, writeSourceMap(dst, orig, res.SourceMaps[0]))
+
+	u := &unmapper{names: map[string]map[string]string{}}
+	require.NoError(t, u.load(dir))
+	require.Len(t, u.files, 1)
+	return u
+}
+
+func Test_splitFrameFunc(t *testing.T) {
+	tests := []struct {
+		line                string
+		prefix, sym, suffix string
+	}{
+		{line: "main.main()", sym: "main.main", suffix: "()"},
+		{line: "main.(*a).b(0xc000012345, 0x1)", sym: "main.(*a).b", suffix: "(0xc000012345, 0x1)"},
+		{line: "example.com/m/pkg.f[...](...)", sym: "example.com/m/pkg.f[...]", suffix: "(...)"},
+		{line: "created by main.c in goroutine 1", prefix: "created by ", sym: "main.c", suffix: " in goroutine 1"},
+		{line: "created by main.c", prefix: "created by ", sym: "main.c"},
+		{line: "main.c.func1", sym: "main.c.func1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			prefix, sym, suffix := splitFrameFunc(tt.line)
+			assert.Equal(t, tt.prefix, prefix)
+			assert.Equal(t, tt.sym, sym)
+			assert.Equal(t, tt.suffix, suffix)
+		})
+	}
+}
+
+func Test_splitSymbol(t *testing.T) {
+	tests := []struct {
+		sym   string
+		pkg   string
+		elems []string
+	}{
+		{sym: "main.main", pkg: "main", elems: []string{"main"}},
+		{sym: "main.(*a).b", pkg: "main", elems: []string{"(*a)", "b"}},
+		{sym: "example.com/m/pkg.(*a).b.func1", pkg: "example.com/m/pkg", elems: []string{"(*a)", "b", "func1"}},
+		{sym: "example.com/m/pkg.f[...].func2", pkg: "example.com/m/pkg", elems: []string{"f[...]", "func2"}},
+		{sym: "main.(*t[...]).m", pkg: "main", elems: []string{"(*t[...])", "m"}},
+		{sym: "gopkg.in/yaml%2ev3.f", pkg: "gopkg.in/yaml%2ev3", elems: []string{"f"}},
+		{sym: "runtime", pkg: "runtime"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sym, func(t *testing.T) {
+			pkg, elems := splitSymbol(tt.sym)
+			assert.Equal(t, tt.pkg, pkg)
+			assert.Equal(t, tt.elems, elems)
+		})
+	}
+}
+
+func Test_unmapper_rewrite(t *testing.T) {
+	dir := t.TempDir()
+	u := newUnmapper(t, dir, minify.Options{}, "main.go.orig")
+	min := filepath.ToSlash(filepath.Join(dir, "main.go"))
+
+	tests := []struct {
+		name  string
+		trace string
+		want  string
+	}{
+		{
+			name:  "method",
+			trace: "main.(*a).b(...)\n\t" + min + ":1 +0x1d\n",
+			want:  "main.(*server).handle(...)\n\t" + min + ".orig:5 +0x1d\n",
+		},
+		{
+			name:  "closure",
+			trace: "main.c.func1()\n\t" + min + ":1 +0x1d\ncreated by main.c in goroutine 1\n\t" + min + ":1 +0x2a\n",
+			want:  "main.run.func1()\n\t" + min + ".orig:12 +0x1d\ncreated by main.run in goroutine 1\n\t" + min + ".orig:12 +0x2a\n",
+		},
+		{
+			name:  "trimmed path",
+			trace: "main.c()\n\texample.com/m/main.go:1 +0x1d\n",
+			want:  "main.run()\n\texample.com/m/main.go.orig:12 +0x1d\n",
+		},
+		{
+			name:  "other package",
+			trace: "example.com/other.c()\n\t/go/pkg/mod/example.com/other@v1.0.0/main.go:1 +0x1d\n",
+			want:  "example.com/other.c()\n\t/go/pkg/mod/example.com/other@v1.0.0/main.go:1 +0x1d\n",
+		},
+		{
+			name:  "other file",
+			trace: "runtime.main()\n\t/usr/local/go/src/runtime/proc.go:271 +0x29e\n",
+			want:  "runtime.main()\n\t/usr/local/go/src/runtime/proc.go:271 +0x29e\n",
+		},
+		{
+			name:  "unknown line",
+			trace: "main.c()\n\t" + min + ":3 +0x1d\n",
+			want:  "main.run()\n\t" + min + ":3 +0x1d\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(u.rewrite([]byte(tt.trace))))
+		})
+	}
+}
+
+func Test_unmapper_rewrite_lineDirectives(t *testing.T) {
+	tests := []struct {
+		name string
+		// parsed is the name the source is parsed as, which the directives name
+		parsed string
+		trace  func(min string) string
+		want   func(min string) string
+	}{
+		{
+			name:   "directives naming the minified file",
+			parsed: "main.go",
+			trace:  func(min string) string { return "main.(*a).b(...)\n\t" + min + ":7 +0x1d\n" },
+			want:   func(min string) string { return "main.(*server).handle(...)\n\t" + min + ".orig:7 +0x1d\n" },
+		},
+		{
+			name:   "directives naming the original file",
+			parsed: "main.go.orig",
+			trace:  func(min string) string { return "main.(*a).b(...)\n\t" + min + ".orig:7 +0x1d\n" },
+			want:   func(min string) string { return "main.(*server).handle(...)\n\t" + min + ".orig:7 +0x1d\n" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			u := newUnmapper(t, dir, minify.Options{LineDirectives: true}, tt.parsed)
+			require.True(t, u.files[0].lineDirectives)
+
+			min := filepath.ToSlash(filepath.Join(dir, "main.go"))
+			assert.Equal(t, tt.want(min), string(u.rewrite([]byte(tt.trace(min)))))
+		})
+	}
+}
+
+func Test_mappedFile_sourceLine(t *testing.T) {
+	u := newUnmapper(t, t.TempDir(), minify.Options{}, "main.go.orig")
+	f := u.files[0]
+
+	tests := []struct {
+		name   string
+		line   int
+		elems  []string
+		want   int
+		wantOK bool
+	}{
+		{name: "method", line: 1, elems: []string{"(*a)", "b"}, want: 5, wantOK: true},
+		{name: "function", line: 1, elems: []string{"c"}, want: 12, wantOK: true},
+		{name: "closure", line: 1, elems: []string{"c", "func1"}, want: 12, wantOK: true},
+		{name: "main", line: 1, elems: []string{"main"}, want: 19, wantOK: true},
+		{name: "unknown function", line: 1, elems: []string{"x"}, want: 3, wantOK: true},
+		{name: "no function", line: 1, want: 3, wantOK: true},
+		{name: "line out of range", line: 2, elems: []string{"c"}},
+		{name: "line zero", line: 0, elems: []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := f.sourceLine(tt.line, tt.elems)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
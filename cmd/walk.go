@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koki-develop/mingo/internal/ignore"
+)
+
+// ignoreFile is the name of the files listing paths to skip, in gitignore syntax.
+const ignoreFile = ".mingoignore"
+
+// walker collects the files to process under a single argument.
+type walker struct {
+	root     string
+	excludes ignore.List
+	includes ignore.List
+
+	// ignores holds the patterns of the ignore file of each walked directory,
+	// and of the parents of the root up to its module root, by absolute path.
+	ignores map[string]ignore.List
+	// top is the absolute path of the highest directory whose ignore file applies.
+	top string
+
+	paths, others []string
+}
+
+// walk returns the Go files to minify under the given files and directories,
+// and, with --copy, the other files to copy to --out-dir.
+func walk(args []string) (paths, others []string, err error) {
+	excludes, err := parsePatterns(flagExcludes)
+	if err != nil {
+		return nil, nil, err
+	}
+	includes, err := parsePatterns(flagIncludes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, root := range args {
+		w := &walker{
+			root:     root,
+			excludes: excludes,
+			includes: includes,
+			ignores:  map[string]ignore.List{},
+		}
+		if err := w.loadParents(); err != nil {
+			return nil, nil, err
+		}
+		if err := filepath.WalkDir(root, w.visit); err != nil {
+			return nil, nil, err
+		}
+		paths = append(paths, w.paths...)
+		others = append(others, w.others...)
+	}
+	return paths, others, nil
+}
+
+func parsePatterns(lines []string) (ignore.List, error) {
+	var l ignore.List
+	for _, line := range lines {
+		p, ok := ignore.ParsePattern(line)
+		if !ok || p.Negate {
+			return nil, errors.New("invalid pattern: " + line)
+		}
+		l = append(l, p)
+	}
+	return l, nil
+}
+
+func (w *walker) visit(path string, d fs.DirEntry, err error) error {
+	if err != nil {
+		return err
+	}
+
+	// files and directories given as arguments are always processed
+	if path != w.root && w.skip(path, d) {
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+
+	if d.IsDir() {
+		if flagOutDir != "" && isOutDir(path) {
+			return filepath.SkipDir
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		return w.loadIgnoreFile(abs)
+	}
+
+	if filepath.Ext(path) != ".go" {
+		if flagCopy {
+			w.others = append(w.others, path)
+		}
+		return nil
+	}
+
+	w.paths = append(w.paths, path)
+	return nil
+}
+
+// skip reports whether path, below the root, should not be processed.
+func (w *walker) skip(path string, d fs.DirEntry) bool {
+	// the go tool ignores these as well
+	name := d.Name()
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+	if d.IsDir() && (name == "vendor" || name == "testdata") {
+		return true
+	}
+
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if ignored, _ := w.excludes.Match(rel, d.IsDir()); ignored {
+		return true
+	}
+	if !d.IsDir() && len(w.includes) > 0 {
+		if included, _ := w.includes.Match(rel, false); !included {
+			return true
+		}
+	}
+
+	// the ignore files of deeper directories take precedence
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if l, ok := w.ignores[dir]; ok {
+			rel, err := filepath.Rel(dir, abs)
+			if err == nil {
+				if ignored, ok := l.Match(filepath.ToSlash(rel), d.IsDir()); ok {
+					return ignored
+				}
+			}
+		}
+		if dir == w.top || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// loadParents loads the ignore files of the parent directories of the root,
+// up to the module root, which contains go.mod. As in git, they apply to the
+// files below the root as well. Outside of a module, only the ignore files
+// at or below the root apply.
+func (w *walker) loadParents() error {
+	root, err := filepath.Abs(w.root)
+	if err != nil {
+		return err
+	}
+	w.top = root
+
+	var parents []string
+	for dir := root; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			w.top = dir
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			return nil
+		}
+		if dir != root {
+			parents = append(parents, dir)
+		}
+	}
+	if w.top != root {
+		parents = append(parents, w.top)
+	}
+
+	for _, dir := range parents {
+		if err := w.loadIgnoreFile(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadIgnoreFile loads the ignore file of the directory at the absolute path dir, if any.
+func (w *walker) loadIgnoreFile(dir string) error {
+	b, err := os.ReadFile(filepath.Join(dir, ignoreFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(b) > 0 {
+		w.ignores[dir] = ignore.Parse(b)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_walk(t *testing.T) {
+	// the ignore file above the module root must not apply
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, ignoreFile), []byte("*.go\n"), 0o644))
+	root := filepath.Join(tmp, "m")
+
+	files := map[string]string{
+		"go.mod":           "module example.com/m\n",
+		".mingoignore":     "*_gen.go\n/top.go\n",
+		"top.go":           "",
+		"a/.mingoignore":   "!keep_gen.go\nskip/\n",
+		"a/a.go":           "",
+		"a/a_gen.go":       "",
+		"a/keep_gen.go":    "",
+		"a/skip/s.go":      "",
+		"a/b/b.go":         "",
+		"a/b/b_gen.go":     "",
+		"a/b/keep_gen.go":  "",
+		"a/b/.mingoignore": "b.go\n",
+		"a/b/c/c.go":       "",
+		"a/b/c/top.go":     "",
+		"a/testdata/t.go":  "",
+		"a/vendor/v.go":    "",
+		"a/_x/x.go":        "",
+		"a/.hidden/h.go":   "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	tests := []struct {
+		name     string
+		arg      string
+		excludes []string
+		includes []string
+		want     []string
+	}{
+		{
+			name: "module root",
+			arg:  ".",
+			want: []string{"a/a.go", "a/b/c/c.go", "a/b/c/top.go", "a/b/keep_gen.go", "a/keep_gen.go"},
+		},
+		{
+			name: "ignore files of parent directories",
+			arg:  "a/b",
+			want: []string{"a/b/c/c.go", "a/b/c/top.go", "a/b/keep_gen.go"},
+		},
+		{
+			name: "anchored patterns of parent directories",
+			arg:  "a/b/c",
+			want: []string{"a/b/c/c.go", "a/b/c/top.go"},
+		},
+		{
+			name: "arguments are always processed",
+			arg:  "a/a_gen.go",
+			want: []string{"a/a_gen.go"},
+		},
+		{
+			name:     "excludes",
+			arg:      "a",
+			excludes: []string{"c/"},
+			want:     []string{"a/a.go", "a/b/keep_gen.go", "a/keep_gen.go"},
+		},
+		{
+			name:     "includes",
+			arg:      "a",
+			includes: []string{"keep_*.go"},
+			want:     []string{"a/b/keep_gen.go", "a/keep_gen.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagExcludes, flagIncludes = tt.excludes, tt.includes
+			t.Cleanup(func() { flagExcludes, flagIncludes = nil, nil })
+
+			paths, _, err := walk([]string{filepath.Join(root, tt.arg)})
+			require.NoError(t, err)
+
+			var got []string
+			for _, path := range paths {
+				rel, err := filepath.Rel(root, path)
+				require.NoError(t, err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFile replaces the content of path with min, keeping a copy of src
+// in path+--backup if a backup suffix is given. If path is a symbolic link,
+// the file it points to is written instead, and the backup is kept next to it.
+// The file is never left partially written, and its mode bits and, where
+// permitted, its owner are preserved.
+func writeFile(path string, src, min []byte) error {
+	path = realPath(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if flagBackup != "" {
+		if err := writeAtomic(path+flagBackup, src, info); err != nil {
+			return err
+		}
+	}
+	return writeAtomic(path, min, info)
+}
+
+// realPath returns path with its symbolic links resolved, or path itself if they cannot be.
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+// writeAtomic writes b to a temporary file in the directory of path
+// and renames it to path, so that path has either its previous or its new content.
+// If path is a symbolic link, the file it points to is written instead.
+// The file gets the mode bits and, where permitted, the owner of like,
+// or the mode 0644 if like is nil.
+func writeAtomic(path string, b []byte, like os.FileInfo) (err error) {
+	path = realPath(path)
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	mode := os.FileMode(0o644)
+	if like != nil {
+		// changing the owner clears the setuid and setgid bits, so it comes first
+		if err := chown(f, like); err != nil {
+			return err
+		}
+		mode = like.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	}
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
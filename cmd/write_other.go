@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// chown does nothing on systems without Unix file ownership.
+func chown(f *os.File, like os.FileInfo) error {
+	return nil
+}
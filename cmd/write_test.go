@@ -0,0 +1,56 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeFile_symlink(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "src", "main.go")
+	link := filepath.Join(dir, "link", "main.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(real), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(link), 0o755))
+	require.NoError(t, os.WriteFile(real, []byte("src"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("..", "src", "main.go"), link))
+
+	// giving files away requires the superuser
+	owned := os.Geteuid() == 0
+	if owned {
+		require.NoError(t, os.Chown(real, 1234, 5678))
+	}
+	// after chown, which clears the setuid bit
+	require.NoError(t, os.Chmod(real, 0o755|os.ModeSetuid))
+
+	flagBackup = ".orig"
+	t.Cleanup(func() { flagBackup = "" })
+	require.NoError(t, writeFile(link, []byte("src"), []byte("min")))
+
+	// the link is kept and the file it points to is written, with its backup next to it
+	target, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "src", "main.go"), target)
+	got, err := os.ReadFile(real)
+	require.NoError(t, err)
+	assert.Equal(t, "min", string(got))
+	backup, err := os.ReadFile(real + ".orig")
+	require.NoError(t, err)
+	assert.Equal(t, "src", string(backup))
+	assert.NoFileExists(t, link+".orig")
+
+	info, err := os.Stat(real)
+	require.NoError(t, err)
+	assert.Equal(t, 0o755|os.ModeSetuid, info.Mode()&(os.ModePerm|os.ModeSetuid))
+	if owned {
+		st := info.Sys().(*syscall.Stat_t)
+		assert.Equal(t, uint32(1234), st.Uid)
+		assert.Equal(t, uint32(5678), st.Gid)
+	}
+}
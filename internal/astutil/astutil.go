@@ -0,0 +1,29 @@
+// Package astutil provides helpers for inspecting syntax trees.
+package astutil
+
+import "go/ast"
+
+// RecvTypeName returns the name of the receiver type of the method fn,
+// without pointers, parentheses and type parameters, or "" if it has none.
+func RecvTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.IndexListExpr:
+			expr = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package astutil
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecvTypeName(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "func f() {}", want: ""},
+		{src: "func (t) m() {}", want: "t"},
+		{src: "func (x *t) m() {}", want: "t"},
+		{src: "func (x (*t)) m() {}", want: "t"},
+		{src: "func (x t[E]) m() {}", want: "t"},
+		{src: "func (x *t[K, V]) m() {}", want: "t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "", "package p;"+tt.src, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, RecvTypeName(file.Decls[0].(*ast.FuncDecl)))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package diff computes line-oriented unified diffs.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type pair struct{ x, y int }
+
+// Unified returns a unified diff from old to new, or nil if they are equal.
+//
+// The diff is anchored on lines that appear exactly once in both inputs,
+// which runs in O(n log n) time and keeps diffs of large files readable,
+// at the cost of not always being minimal.
+func Unified(oldName string, oldSrc []byte, newName string, newSrc []byte) []byte {
+	if bytes.Equal(oldSrc, newSrc) {
+		return nil
+	}
+
+	x, y := lines(oldSrc), lines(newSrc)
+
+	out := new(bytes.Buffer)
+	fmt.Fprintf(out, "diff %s %s\n", oldName, newName)
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+
+	var (
+		done  pair
+		start pair
+		count pair
+		hunk  []string
+	)
+	for _, m := range anchors(x, y) {
+		if m.x < done.x {
+			continue
+		}
+
+		// extend the anchor to the surrounding equal lines
+		lo, hi := m, m
+		for lo.x > done.x && lo.y > done.y && x[lo.x-1] == y[lo.y-1] {
+			lo.x--
+			lo.y--
+		}
+		for hi.x < len(x) && hi.y < len(y) && x[hi.x] == y[hi.y] {
+			hi.x++
+			hi.y++
+		}
+
+		for _, s := range x[done.x:lo.x] {
+			hunk = append(hunk, "-"+s)
+			count.x++
+		}
+		for _, s := range y[done.y:lo.y] {
+			hunk = append(hunk, "+"+s)
+			count.y++
+		}
+
+		eof := hi.x >= len(x) && hi.y >= len(y)
+
+		// too few equal lines to separate two hunks: keep them in this one
+		if !eof && (hi.x-lo.x < context || (len(hunk) > 0 && hi.x-lo.x < 2*context)) {
+			for _, s := range x[lo.x:hi.x] {
+				hunk = append(hunk, " "+s)
+				count.x++
+				count.y++
+			}
+			done = hi
+			continue
+		}
+
+		if len(hunk) > 0 {
+			n := min(hi.x-lo.x, context)
+			for _, s := range x[lo.x : lo.x+n] {
+				hunk = append(hunk, " "+s)
+				count.x++
+				count.y++
+			}
+			done = pair{lo.x + n, lo.y + n}
+
+			// line numbers are 1-based, except for empty ranges
+			if count.x > 0 {
+				start.x++
+			}
+			if count.y > 0 {
+				start.y++
+			}
+			fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", start.x, count.x, start.y, count.y)
+			for _, s := range hunk {
+				out.WriteString(s)
+			}
+			count = pair{}
+			hunk = hunk[:0]
+		}
+
+		if eof {
+			break
+		}
+
+		// start the next hunk with the trailing equal lines as context
+		start = pair{hi.x - context, hi.y - context}
+		for _, s := range x[start.x:hi.x] {
+			hunk = append(hunk, " "+s)
+			count.x++
+			count.y++
+		}
+		done = hi
+	}
+
+	return out.Bytes()
+}
+
+// lines splits b into lines, each ending in a newline.
+// A missing final newline is reported the same way as diff(1) does.
+func lines(b []byte) []string {
+	l := strings.SplitAfter(string(b), "\n")
+	if l[len(l)-1] == "" {
+		return l[:len(l)-1]
+	}
+	l[len(l)-1] += "\n\\ No newline at end of file\n"
+	return l
+}
+
+// anchors returns the longest common subsequence of the lines that appear
+// exactly once in both x and y, as pairs of line indexes, surrounded by the
+// sentinels {0, 0} and {len(x), len(y)}.
+//
+// The subsequence is computed with Szymanski's algorithm for the special case
+// of unique elements, which is a longest increasing subsequence problem.
+func anchors(x, y []string) []pair {
+	// the number of occurrences, saturated at 2, in x and y
+	type occurrence struct{ x, y, yi int }
+	occ := map[string]*occurrence{}
+	for _, s := range x {
+		o, ok := occ[s]
+		if !ok {
+			o = &occurrence{}
+			occ[s] = o
+		}
+		o.x = min(o.x+1, 2)
+	}
+	for i, s := range y {
+		if o, ok := occ[s]; ok {
+			o.y = min(o.y+1, 2)
+			o.yi = i
+		}
+	}
+
+	// the indexes of unique lines in x, and of the same lines in y
+	var xi, yi []int
+	for i, s := range x {
+		if o := occ[s]; o.x == 1 && o.y == 1 {
+			xi = append(xi, i)
+			yi = append(yi, o.yi)
+		}
+	}
+
+	// longest increasing subsequence of yi
+	n := len(yi)
+	tails := []int{} // tails[k] is the smallest yi ending a subsequence of length k+1
+	length := make([]int, n)
+	for i, v := range yi {
+		k := sort.SearchInts(tails, v)
+		if k == len(tails) {
+			tails = append(tails, v)
+		} else {
+			tails[k] = v
+		}
+		length[i] = k + 1
+	}
+
+	k := len(tails)
+	seq := make([]pair, k+2)
+	seq[0] = pair{0, 0}
+	seq[k+1] = pair{len(x), len(y)}
+	last := len(y)
+	for i := n - 1; i >= 0 && k > 0; i-- {
+		if length[i] == k && yi[i] < last {
+			seq[k] = pair{xi[i], yi[i]}
+			last = yi[i]
+			k--
+		}
+	}
+
+	return seq
+}
@@ -0,0 +1,41 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Unified(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change",
+			old:  "a\nb\nc\nd\ne\nf\ng\nh\ni\n",
+			new:  "a\nb\nc\nd\nE\nf\ng\nh\ni\n",
+			want: "diff old new\n--- old\n+++ new\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			name: "replace everything",
+			old:  "package main\n\nfunc main() {\n}\n",
+			new:  "package main;func main(){};",
+			want: "diff old new\n--- old\n+++ new\n@@ -1,4 +1,1 @@\n-package main\n-\n-func main() {\n-}\n+package main;func main(){};\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", []byte(tt.old), "new", []byte(tt.new))
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package ignore matches paths against patterns in gitignore syntax.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+)
+
+// Pattern is a single gitignore pattern.
+type Pattern struct {
+	// Negate is set for patterns starting with "!", which re-include matching paths.
+	Negate bool
+
+	segs     []string
+	dirOnly  bool
+	anchored bool
+}
+
+// ParsePattern parses a line of a gitignore file.
+// It returns false for blank lines and comments.
+func ParsePattern(line string) (Pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+
+	var p Pattern
+	if strings.HasPrefix(line, "!") {
+		p.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// a separator at the beginning or in the middle anchors the pattern
+	// to the directory of the gitignore file
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false
+	}
+
+	p.segs = strings.Split(line, "/")
+	return p, true
+}
+
+// Match reports whether the slash-separated path rel, relative to the
+// directory of the pattern, matches p. Paths inside a matching directory
+// match as well.
+func (p Pattern) Match(rel string, isDir bool) bool {
+	names := strings.Split(rel, "/")
+	for n := len(names); n > 0; n-- {
+		// every path but the last is a parent directory
+		dir := isDir || n < len(names)
+		if p.dirOnly && !dir {
+			continue
+		}
+		if p.anchored {
+			if matchSegments(p.segs, names[:n]) {
+				return true
+			}
+		} else if ok, _ := path.Match(p.segs[0], names[n-1]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the segments of a pattern, in which "**" matches
+// any number of directories, against the segments of a path.
+func matchSegments(pat, names []string) bool {
+	if len(pat) == 0 {
+		return len(names) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(pat[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], names[0])
+	return ok && matchSegments(pat[1:], names[1:])
+}
+
+// List is the list of patterns of a gitignore file.
+type List []Pattern
+
+// Parse parses the content of a gitignore file.
+func Parse(b []byte) List {
+	var l List
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		if p, ok := ParsePattern(sc.Text()); ok {
+			l = append(l, p)
+		}
+	}
+	return l
+}
+
+// Match reports whether rel is ignored by l, in which case ignored is true,
+// or re-included by a negated pattern. The last matching pattern decides.
+// ok is false if no pattern matches rel.
+func (l List) Match(rel string, isDir bool) (ignored, ok bool) {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].Match(rel, isDir) {
+			return !l[i].Negate, true
+		}
+	}
+	return false, false
+}
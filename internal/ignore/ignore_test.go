@@ -0,0 +1,61 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		{pattern: "*.pb.go", rel: "foo.pb.go", want: true},
+		{pattern: "*.pb.go", rel: "api/v1/foo.pb.go", want: true},
+		{pattern: "*.pb.go", rel: "foo.go", want: false},
+		{pattern: "gen", rel: "gen", isDir: true, want: true},
+		{pattern: "gen", rel: "a/gen/b.go", want: true},
+		{pattern: "gen/", rel: "gen", want: false},
+		{pattern: "gen/", rel: "gen/b.go", want: true},
+		{pattern: "/main.go", rel: "main.go", want: true},
+		{pattern: "/main.go", rel: "cmd/main.go", want: false},
+		{pattern: "cmd/*.go", rel: "cmd/root.go", want: true},
+		{pattern: "cmd/*.go", rel: "x/cmd/root.go", want: false},
+		{pattern: "**/mock", rel: "a/b/mock/m.go", want: true},
+		{pattern: "a/**/b.go", rel: "a/b.go", want: true},
+		{pattern: "a/**/b.go", rel: "a/x/y/b.go", want: true},
+		{pattern: `\#file.go`, rel: "#file.go", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.rel, func(t *testing.T) {
+			p, ok := ParsePattern(tt.pattern)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, p.Match(tt.rel, tt.isDir))
+		})
+	}
+}
+
+func Test_List_Match(t *testing.T) {
+	l := Parse([]byte("# generated code\n*_gen.go\n\n!keep_gen.go\n"))
+	assert.Len(t, l, 2)
+
+	tests := []struct {
+		rel         string
+		wantIgnored bool
+		wantOK      bool
+	}{
+		{rel: "foo_gen.go", wantIgnored: true, wantOK: true},
+		{rel: "keep_gen.go", wantIgnored: false, wantOK: true},
+		{rel: "main.go", wantIgnored: false, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			ignored, ok := l.Match(tt.rel, false)
+			assert.Equal(t, tt.wantIgnored, ignored)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
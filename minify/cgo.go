@@ -0,0 +1,79 @@
+package minify
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// isCgoImport reports whether spec is the import of the cgo pseudo-package "C".
+func isCgoImport(spec *ast.ImportSpec) bool {
+	return spec.Path.Value == `"C"`
+}
+
+// stringifyCgoImport returns the import of "C" in decl as a declaration of
+// its own, preceded by its preamble, which cgo reads from the comment
+// immediately preceding the import.
+func (m *mingo) stringifyCgoImport(decl *ast.GenDecl, spec *ast.ImportSpec) string {
+	sb := new(strings.Builder)
+
+	cg := spec.Doc
+	if cg == nil && len(decl.Specs) == 1 {
+		cg = decl.Doc
+	}
+	if cg != nil {
+		sb.WriteString("\n")
+		if m.options.MinifyCgoPreamble {
+			sb.WriteString(minifyPreamble(cgoPreamble(cg)))
+		} else {
+			for _, c := range cg.List {
+				sb.WriteString(c.Text + "\n")
+			}
+		}
+	}
+
+	sb.WriteString(`import "C";`)
+	return sb.String()
+}
+
+// cgoPreamble returns the C code in cg, the same way as cgo extracts it.
+func cgoPreamble(cg *ast.CommentGroup) string {
+	sb := new(strings.Builder)
+	for _, c := range cg.List {
+		if strings.HasPrefix(c.Text, "//") {
+			sb.WriteString(c.Text[2:] + "\n")
+		} else {
+			sb.WriteString(c.Text[2 : len(c.Text)-2])
+		}
+	}
+	return sb.String()
+}
+
+// minifyPreamble removes the indentation, trailing spaces and blank lines
+// of the C code of a preamble, and returns it as a comment.
+// Lines are kept, as preprocessor directives and #cgo lines end at a newline.
+func minifyPreamble(preamble string) string {
+	var lines []string
+	continued := false
+	for _, line := range strings.Split(preamble, "\n") {
+		// the leading spaces of a continued line may be part of a string literal
+		if !continued {
+			line = strings.TrimLeft(line, " \t")
+		}
+		line = strings.TrimRight(line, " \t\r")
+		continued = strings.HasSuffix(line, "\\")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	code := strings.Join(lines, "\n")
+	if !strings.Contains(code, "*/") {
+		return "/*" + code + "*/\n"
+	}
+
+	sb := new(strings.Builder)
+	for _, line := range lines {
+		sb.WriteString("//" + line + "\n")
+	}
+	return sb.String()
+}
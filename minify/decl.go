@@ -1,4 +1,4 @@
-package mingo
+package minify
 
 import (
 	"fmt"
@@ -10,11 +10,12 @@ import (
 func (m *mingo) stringifyDecl(decl ast.Decl) string {
 	switch x := decl.(type) {
 	case *ast.GenDecl:
-		return m.stringifyGenDecl(x)
+		return m.stringifyDirectives(x) + m.stringifyPos(x.Pos()) + m.stringifyGenDecl(x)
 	case *ast.FuncDecl:
-		return m.stringifyFuncDecl(x)
+		return m.stringifyDirectives(x) + m.stringifyPos(x.Pos()) + m.stringifyFuncDecl(x)
+	default:
+		return m.unsupported(decl)
 	}
-	return ""
 }
 
 func (m *mingo) stringifyGenDecl(n *ast.GenDecl) string {
@@ -27,38 +28,58 @@ func (m *mingo) stringifyGenDecl(n *ast.GenDecl) string {
 		return m.stringifyVarDecl(n)
 	case token.TYPE:
 		return m.stringifyTypeSpec(n)
+	default:
+		return m.unsupported(n)
 	}
-
-	return ""
 }
 
 func (m *mingo) stringifyImportDecl(decl *ast.GenDecl) string {
+	var specs []*ast.ImportSpec
+	var cgo *ast.ImportSpec
+	for _, n := range decl.Specs {
+		n := n.(*ast.ImportSpec)
+		if m.unusedImports[n] {
+			continue
+		}
+		if isCgoImport(n) {
+			cgo = n
+			continue
+		}
+		specs = append(specs, n)
+	}
+
 	sb := new(strings.Builder)
-	sb.WriteString("import")
 
-	if len(decl.Specs) > 1 {
-		sb.WriteString("(")
-	} else {
-		sb.WriteString(" ")
-	}
+	if len(specs) > 0 {
+		sb.WriteString("import")
 
-	for i, n := range decl.Specs {
-		n := n.(*ast.ImportSpec)
+		if len(specs) > 1 {
+			sb.WriteString("(")
+		} else {
+			sb.WriteString(" ")
+		}
 
-		if i > 0 {
-			sb.WriteString(";")
+		for i, n := range specs {
+			if i > 0 {
+				sb.WriteString(";")
+			}
+			if n.Name != nil {
+				sb.WriteString(fmt.Sprintf("%s %s", m.stringifyIdent(n.Name), n.Path.Value))
+			} else {
+				sb.WriteString(n.Path.Value)
+			}
 		}
-		if n.Name != nil {
-			sb.WriteString(fmt.Sprintf("%s %s", n.Name.Name, n.Path.Value))
-		} else {
-			sb.WriteString(n.Path.Value)
+
+		if len(specs) > 1 {
+			sb.WriteString(")")
 		}
+		sb.WriteString(";")
 	}
 
-	if len(decl.Specs) > 1 {
-		sb.WriteString(")")
+	if cgo != nil {
+		sb.WriteString(m.stringifyCgoImport(decl, cgo))
 	}
-	sb.WriteString(";")
+
 	return sb.String()
 }
 
@@ -82,7 +103,7 @@ func (m *mingo) stringifyConstDecl(decl *ast.GenDecl) string {
 			if j > 0 {
 				sb.WriteString(",")
 			}
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 
 		if spec.Type != nil {
@@ -110,15 +131,6 @@ func (m *mingo) stringifyConstDecl(decl *ast.GenDecl) string {
 
 func (m *mingo) stringifyVarDecl(decl *ast.GenDecl) string {
 	sb := new(strings.Builder)
-
-	if decl.Doc != nil {
-		for _, cmt := range decl.Doc.List {
-			if strings.HasPrefix(cmt.Text, "//go:embed ") {
-				fmt.Fprintf(sb, "\n%s\n", cmt.Text)
-			}
-		}
-	}
-
 	sb.WriteString("var")
 
 	if len(decl.Specs) > 1 {
@@ -133,19 +145,12 @@ func (m *mingo) stringifyVarDecl(decl *ast.GenDecl) string {
 		if i > 0 {
 			sb.WriteString(";")
 		}
+		sb.WriteString(m.stringifyDirectives(spec))
 		for j, name := range spec.Names {
 			if j > 0 {
 				sb.WriteString(",")
 			}
-
-			if spec.Doc != nil {
-				for _, cmt := range spec.Doc.List {
-					if strings.HasPrefix(cmt.Text, "//go:embed ") {
-						fmt.Fprintf(sb, "\n%s\n", cmt.Text)
-					}
-				}
-			}
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 
 		if spec.Type != nil {
@@ -177,7 +182,8 @@ func (m *mingo) stringifyTypeSpec(decl *ast.GenDecl) string {
 	for _, n := range decl.Specs {
 		n := n.(*ast.TypeSpec)
 
-		sb.WriteString(fmt.Sprintf("type %s", n.Name.Name))
+		sb.WriteString(m.stringifyDirectives(n))
+		sb.WriteString(fmt.Sprintf("type %s", m.stringifyIdent(n.Name)))
 		if n.TypeParams != nil {
 			sb.WriteString(m.stringifyFuncTypeParams(n.TypeParams))
 		}
@@ -0,0 +1,107 @@
+package minify
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// headerDirectives are the directives hoisted above the package clause.
+// The go command reads them from the file header, regardless of where
+// go:generate directives appear.
+var headerDirectives = []string{"//go:build ", "// +build ", "//go:debug ", "//go:generate "}
+
+func isHeaderDirective(c *ast.Comment) bool {
+	for _, prefix := range headerDirectives {
+		if strings.HasPrefix(c.Text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDirective reports whether c is a compiler directive, such as go:noinline
+// or go:embed, or a cgo export directive, that applies to the declaration
+// following it.
+func isDirective(c *ast.Comment) bool {
+	if strings.HasPrefix(c.Text, "//export ") {
+		return true
+	}
+	return strings.HasPrefix(c.Text, "//go:") && !isHeaderDirective(c)
+}
+
+// attachDirectives records the directives of file by the declaration or
+// spec they precede. Directives after the last declaration are recorded
+// by file, and directives inside function bodies are dropped, as the
+// compiler ignores them.
+func (m *mingo) attachDirectives(file *ast.File) {
+	m.directives = map[ast.Node][]*ast.Comment{}
+
+	for _, cg := range file.Comments {
+		if cg.Pos() < file.Package {
+			continue
+		}
+		for _, c := range cg.List {
+			if !isDirective(c) {
+				continue
+			}
+			if n := directiveTarget(file, c); n != nil {
+				m.directives[n] = append(m.directives[n], c)
+			}
+		}
+	}
+}
+
+func directiveTarget(file *ast.File, c *ast.Comment) ast.Node {
+	for _, decl := range file.Decls {
+		if decl.End() < c.Pos() {
+			continue
+		}
+		if c.Pos() < decl.Pos() {
+			return decl
+		}
+		// the directive is inside a declaration
+		if gen, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range gen.Specs {
+				if c.Pos() < spec.Pos() {
+					return spec
+				}
+			}
+		}
+		return nil
+	}
+	return file
+}
+
+// stringifyDirectives returns the directives preceding n, each on its own line.
+func (m *mingo) stringifyDirectives(n ast.Node) string {
+	cs := m.directives[n]
+	if len(cs) == 0 {
+		return ""
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("\n")
+	for _, c := range cs {
+		sb.WriteString(c.Text + "\n")
+	}
+	return sb.String()
+}
+
+// keepDirectiveNames keeps the names of the package-level objects named by
+// go:linkname and cgo export directives, which refer to them by name at link time.
+func (r *renamer) keepDirectiveNames(pkg *types.Package) {
+	for _, file := range r.files {
+		for _, cg := range file.Comments {
+			for _, c := range cg.List {
+				fields := strings.Fields(c.Text)
+				if len(fields) < 2 || (fields[0] != "//go:linkname" && fields[0] != "//export") {
+					continue
+				}
+				if obj := pkg.Scope().Lookup(fields[1]); obj != nil {
+					r.keep[obj] = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package minify
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// escapeAnalysis finds the named types whose values may be converted to an
+// interface, either by the package itself or by its importers.
+// Reflection can only reach values through interfaces, so the names of
+// types and fields that do not escape are not observable at run time.
+type escapeAnalysis struct {
+	info *types.Info
+
+	escaping   map[*types.TypeName]bool
+	seen       map[types.Type]bool
+	typeParams bool
+}
+
+func escapingTypes(pkg *types.Package, info *types.Info, files []*ast.File) map[*types.TypeName]bool {
+	e := &escapeAnalysis{
+		info:     info,
+		escaping: map[*types.TypeName]bool{},
+		seen:     map[types.Type]bool{},
+	}
+
+	// everything reachable from the exported API may be inspected by importers
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if obj := scope.Lookup(name); obj.Exported() {
+			e.escape(obj.Type())
+		}
+	}
+
+	for _, file := range files {
+		e.walk(file)
+	}
+
+	// values of type parameters are converted to interfaces,
+	// so every type argument may escape
+	if e.typeParams {
+		for _, inst := range info.Instances {
+			for i := 0; i < inst.TypeArgs.Len(); i++ {
+				e.escape(inst.TypeArgs.At(i))
+			}
+		}
+	}
+
+	return e.escaping
+}
+
+// escape marks t and every type reachable from its values as escaping.
+func (e *escapeAnalysis) escape(t types.Type) {
+	if t == nil || e.seen[t] {
+		return
+	}
+	e.seen[t] = true
+
+	switch x := t.(type) {
+	case *types.Named:
+		e.escaping[x.Origin().Obj()] = true
+		e.escape(x.Underlying())
+		if args := x.TypeArgs(); args != nil {
+			for i := 0; i < args.Len(); i++ {
+				e.escape(args.At(i))
+			}
+		}
+		for i := 0; i < x.NumMethods(); i++ {
+			if m := x.Method(i); m.Exported() {
+				e.escape(m.Type())
+			}
+		}
+	case *types.Alias:
+		e.escape(types.Unalias(x))
+	case *types.Pointer:
+		e.escape(x.Elem())
+	case *types.Slice:
+		e.escape(x.Elem())
+	case *types.Array:
+		e.escape(x.Elem())
+	case *types.Chan:
+		e.escape(x.Elem())
+	case *types.Map:
+		e.escape(x.Key())
+		e.escape(x.Elem())
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			e.escape(x.Field(i).Type())
+		}
+	case *types.Signature:
+		e.escape(x.Results())
+	case *types.Tuple:
+		for i := 0; i < x.Len(); i++ {
+			e.escape(x.At(i).Type())
+		}
+	case *types.TypeParam:
+		e.typeParams = true
+	}
+}
+
+// assign records that a value of type from is assigned to a variable of type to.
+func (e *escapeAnalysis) assign(to, from types.Type) {
+	if to == nil || from == nil {
+		return
+	}
+	if _, ok := to.(*types.TypeParam); ok || !types.IsInterface(to) {
+		return
+	}
+	if _, ok := from.(*types.TypeParam); !ok && types.IsInterface(from) {
+		return
+	}
+	e.escape(from)
+}
+
+func (e *escapeAnalysis) assignExpr(to types.Type, expr ast.Expr) {
+	e.assign(to, e.typeOf(expr))
+}
+
+// assignList records the assignment of values to variables of types to,
+// including the assignment of a single multi-valued expression.
+func (e *escapeAnalysis) assignList(to []types.Type, values []ast.Expr) {
+	if len(values) == 1 && len(to) > 1 {
+		if tuple, ok := e.typeOf(values[0]).(*types.Tuple); ok {
+			for i := 0; i < tuple.Len() && i < len(to); i++ {
+				e.assign(to[i], tuple.At(i).Type())
+			}
+		}
+		return
+	}
+	for i, value := range values {
+		if i < len(to) {
+			e.assignExpr(to[i], value)
+		}
+	}
+}
+
+func (e *escapeAnalysis) typeOf(expr ast.Expr) types.Type {
+	if tv, ok := e.info.Types[expr]; ok {
+		return tv.Type
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		if obj := e.info.ObjectOf(id); obj != nil {
+			return obj.Type()
+		}
+	}
+	return nil
+}
+
+func (e *escapeAnalysis) walk(file *ast.File) {
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		switch x := n.(type) {
+		case *ast.CallExpr:
+			e.walkCallExpr(x)
+		case *ast.AssignStmt:
+			if x.Tok == token.ASSIGN {
+				var to []types.Type
+				for _, lhs := range x.Lhs {
+					to = append(to, e.typeOf(lhs))
+				}
+				e.assignList(to, x.Rhs)
+			}
+		case *ast.ValueSpec:
+			if x.Type != nil {
+				to := make([]types.Type, len(x.Names))
+				for i := range to {
+					to[i] = e.typeOf(x.Type)
+				}
+				e.assignList(to, x.Values)
+			}
+		case *ast.ReturnStmt:
+			if sig := e.enclosingSignature(stack); sig != nil {
+				var to []types.Type
+				for i := 0; i < sig.Results().Len(); i++ {
+					to = append(to, sig.Results().At(i).Type())
+				}
+				e.assignList(to, x.Results)
+			}
+		case *ast.CompositeLit:
+			e.walkCompositeLit(x)
+		case *ast.SendStmt:
+			if ch, ok := coreType(e.typeOf(x.Chan)).(*types.Chan); ok {
+				e.assignExpr(ch.Elem(), x.Value)
+			}
+		case *ast.IndexExpr:
+			if m, ok := coreType(e.typeOf(x.X)).(*types.Map); ok {
+				e.assignExpr(m.Key(), x.Index)
+			}
+		}
+		return true
+	})
+}
+
+func (e *escapeAnalysis) walkCallExpr(call *ast.CallExpr) {
+	tv, ok := e.info.Types[call.Fun]
+	if !ok {
+		return
+	}
+
+	if tv.IsType() {
+		for _, arg := range call.Args {
+			e.assignExpr(tv.Type, arg)
+		}
+		return
+	}
+
+	sig, ok := coreType(tv.Type).(*types.Signature)
+	if !ok {
+		return
+	}
+
+	params := sig.Params()
+	var to []types.Type
+	for i := 0; i < params.Len(); i++ {
+		to = append(to, params.At(i).Type())
+	}
+	if sig.Variadic() && !call.Ellipsis.IsValid() && len(to) > 0 {
+		if variadic, ok := coreType(to[len(to)-1]).(*types.Slice); ok {
+			to = to[:len(to)-1]
+			for len(to) < len(call.Args) {
+				to = append(to, variadic.Elem())
+			}
+		}
+	}
+	e.assignList(to, call.Args)
+}
+
+func (e *escapeAnalysis) walkCompositeLit(lit *ast.CompositeLit) {
+	t := coreType(e.typeOf(lit))
+	if p, ok := t.(*types.Pointer); ok {
+		t = coreType(p.Elem())
+	}
+
+	for i, elt := range lit.Elts {
+		kv, isKV := elt.(*ast.KeyValueExpr)
+
+		switch x := t.(type) {
+		case *types.Struct:
+			if isKV {
+				if key, ok := kv.Key.(*ast.Ident); ok {
+					if field, ok := e.info.Uses[key].(*types.Var); ok {
+						e.assignExpr(field.Type(), kv.Value)
+					}
+				}
+			} else if i < x.NumFields() {
+				e.assignExpr(x.Field(i).Type(), elt)
+			}
+		case *types.Slice, *types.Array:
+			elem := x.(interface{ Elem() types.Type }).Elem()
+			if isKV {
+				e.assignExpr(elem, kv.Value)
+			} else {
+				e.assignExpr(elem, elt)
+			}
+		case *types.Map:
+			if isKV {
+				e.assignExpr(x.Key(), kv.Key)
+				e.assignExpr(x.Elem(), kv.Value)
+			}
+		}
+	}
+}
+
+func (e *escapeAnalysis) enclosingSignature(stack []ast.Node) *types.Signature {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch x := stack[i].(type) {
+		case *ast.FuncDecl:
+			if obj := e.info.Defs[x.Name]; obj != nil {
+				sig, _ := obj.Type().(*types.Signature)
+				return sig
+			}
+			return nil
+		case *ast.FuncLit:
+			sig, _ := e.typeOf(x).(*types.Signature)
+			return sig
+		}
+	}
+	return nil
+}
+
+// coreType returns the underlying type of t, or nil if t is nil.
+func coreType(t types.Type) types.Type {
+	if t == nil {
+		return nil
+	}
+	return t.Underlying()
+}
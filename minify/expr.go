@@ -1,4 +1,4 @@
-package mingo
+package minify
 
 import (
 	"fmt"
@@ -50,11 +50,20 @@ func (m *mingo) stringifyExpr(expr ast.Expr) string {
 		return m.stringifyFuncType(x)
 	case *ast.IndexListExpr:
 		return m.stringifyIndexListExpr(x)
+	case *ast.Ident:
+		return m.stringifyIdent(x)
 	case nil:
 		return ""
+	default:
+		return m.unsupported(expr)
 	}
+}
 
-	return expr.(*ast.Ident).Name
+func (m *mingo) stringifyIdent(expr *ast.Ident) string {
+	if name, ok := m.renames[expr]; ok {
+		return m.mark(expr.Pos(), expr.Name) + name
+	}
+	return m.mark(expr.Pos(), "") + expr.Name
 }
 
 func (m *mingo) stringifyIndexListExpr(expr *ast.IndexListExpr) string {
@@ -76,14 +85,14 @@ func (m *mingo) stringifyIndexListExpr(expr *ast.IndexListExpr) string {
 func (m *mingo) stringifySelectExpr(expr *ast.SelectorExpr) string {
 	switch x := expr.X.(type) {
 	case *ast.SelectorExpr:
-		return fmt.Sprintf("%s.%s", m.stringifySelectExpr(x), expr.Sel.Name)
+		return fmt.Sprintf("%s.%s", m.stringifySelectExpr(x), m.stringifyIdent(expr.Sel))
 	default:
-		return fmt.Sprintf("%s.%s", m.stringifyExpr(expr.X), expr.Sel.Name)
+		return fmt.Sprintf("%s.%s", m.stringifyExpr(expr.X), m.stringifyIdent(expr.Sel))
 	}
 }
 
 func (m *mingo) stringifyBasicLit(lit *ast.BasicLit) string {
-	return lit.Value
+	return m.mark(lit.Pos(), "") + lit.Value
 }
 
 func (m *mingo) stringifyCallExpr(expr *ast.CallExpr) string {
@@ -112,7 +121,7 @@ func (m *mingo) stringifyStarExpr(expr *ast.StarExpr) string {
 }
 
 func (m *mingo) stringifyArrayType(expr *ast.ArrayType) string {
-	return fmt.Sprintf("[]%s", m.stringifyExpr(expr.Elt))
+	return fmt.Sprintf("[%s]%s", m.stringifyExpr(expr.Len), m.stringifyExpr(expr.Elt))
 }
 
 func (m *mingo) stringifyEllipsis(expr *ast.Ellipsis) string {
@@ -195,11 +204,21 @@ func (m *mingo) stringifyTypeAssertExpr(expr *ast.TypeAssertExpr) string {
 func (m *mingo) stringifyChanType(expr *ast.ChanType) string {
 	sb := new(strings.Builder)
 
-	if expr.Dir == ast.RECV {
-		sb.WriteString("<-")
+	switch expr.Dir {
+	case ast.SEND:
+		sb.WriteString("chan<-")
+	case ast.RECV:
+		sb.WriteString("<-chan ")
+	default:
+		sb.WriteString("chan ")
+	}
+
+	// "chan <-chan T" is parsed as "chan<- chan T", so the element type needs parentheses
+	if v, ok := expr.Value.(*ast.ChanType); ok && expr.Dir == ast.SEND|ast.RECV && v.Dir == ast.RECV {
+		fmt.Fprintf(sb, "(%s)", m.stringifyExpr(v))
+	} else {
+		sb.WriteString(m.stringifyExpr(expr.Value))
 	}
-	sb.WriteString("chan ")
-	sb.WriteString(m.stringifyExpr(expr.Value))
 
 	return sb.String()
 }
@@ -217,7 +236,7 @@ func (m *mingo) stringifyInterfaceType(expr *ast.InterfaceType) string {
 			sb.WriteString(";")
 		}
 		for _, name := range field.Names {
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 		if f, ok := field.Type.(*ast.FuncType); ok {
 			sb.WriteString(m.stringifyFuncTypeParams(f.TypeParams))
@@ -244,7 +263,7 @@ func (m *mingo) stringifyStructType(expr *ast.StructType) string {
 			if j > 0 {
 				sb.WriteString(",")
 			}
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 		if len(field.Names) > 0 {
 			sb.WriteString(" ")
@@ -0,0 +1,85 @@
+package minify
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+func (m *mingo) stringifyFile(n *ast.File) string {
+	return fmt.Sprintf("package %s;", n.Name.Name)
+}
+
+// stringifyHeader returns the comments kept before the package clause,
+// each on its own line: directives first, as build constraints must not
+// follow /* */ comments, then the comments kept by Options.KeepHeader and
+// Options.KeepComments, then the generated code marker.
+func (m *mingo) stringifyHeader(n *ast.File) string {
+	sb := new(strings.Builder)
+
+	for _, cg := range n.Comments {
+		for _, c := range cg.List {
+			if isHeaderDirective(c) {
+				sb.WriteString(c.Text + "\n")
+			}
+		}
+	}
+
+	generated := generatedComment(n)
+	// the header is the first comment group with more than directives
+	header := m.options.KeepHeader
+	for _, cg := range n.Comments {
+		if cg.Pos() > n.Package {
+			break
+		}
+		inHeader := false
+		for _, c := range cg.List {
+			if c == generated || isHeaderDirective(c) {
+				continue
+			}
+			if header {
+				inHeader = true
+			}
+			if inHeader || m.keepComment(c) {
+				sb.WriteString(c.Text + "\n")
+			}
+		}
+		if inHeader {
+			header = false
+		}
+	}
+
+	if generated != nil {
+		sb.WriteString(generated.Text + "\n")
+	}
+
+	return sb.String()
+}
+
+func (m *mingo) keepComment(c *ast.Comment) bool {
+	for _, re := range m.options.KeepComments {
+		if re.MatchString(c.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+var generatedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedComment returns the comment marking file as generated,
+// following the same convention as ast.IsGenerated.
+func generatedComment(file *ast.File) *ast.Comment {
+	for _, cg := range file.Comments {
+		if cg.Pos() > file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if generatedPattern.MatchString(c.Text) {
+				return c
+			}
+		}
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-package mingo
+package minify
 
 import (
 	"fmt"
@@ -17,12 +17,27 @@ func (m *mingo) stringifyFuncDecl(n *ast.FuncDecl) string {
 		sb.WriteString(" ")
 	}
 
-	fmt.Fprintf(sb, "%s", n.Name.Name)
+	fmt.Fprintf(sb, "%s", m.stringifyIdent(n.Name))
 
 	sb.WriteString(m.stringifyFuncTypeParams(n.Type.TypeParams))
 	sb.WriteString(m.stringifyFuncParams(n.Type.Params))
 	sb.WriteString(m.stringifyFuncResults(n.Type.Results))
-	sb.WriteString(m.stringifyBlockStmt(n.Body))
+	// functions implemented in assembly or by go:linkname have no body
+	if n.Body == nil {
+		sb.WriteString(";")
+		return sb.String()
+	}
+
+	body := m.stringifyBlockStmt(n.Body)
+	if cg, ok := m.examples[n]; ok {
+		// the output comment must be the last comment in the body
+		body = strings.TrimSuffix(body, "}")
+		for _, c := range cg.List {
+			body += "\n" + c.Text
+		}
+		body += "\n}"
+	}
+	sb.WriteString(body)
 
 	sb.WriteString(";")
 	return sb.String()
@@ -44,7 +59,7 @@ func (m *mingo) stringifyFuncTypeParams(params *ast.FieldList) string {
 			if j > 0 {
 				sb.WriteString(",")
 			}
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 		if len(param.Names) > 0 {
 			sb.WriteString(" ")
@@ -69,7 +84,7 @@ func (m *mingo) stringifyFuncParams(params *ast.FieldList) string {
 			if j > 0 {
 				sb.WriteString(",")
 			}
-			sb.WriteString(name.Name)
+			sb.WriteString(m.stringifyIdent(name))
 		}
 
 		if len(arg.Names) > 0 {
@@ -106,7 +121,7 @@ func (m *mingo) stringifyFuncResults(results *ast.FieldList) string {
 			if j > 0 {
 				rb.WriteString(",")
 			}
-			rb.WriteString(name.Name)
+			rb.WriteString(m.stringifyIdent(name))
 			rb.WriteString(" ")
 		}
 
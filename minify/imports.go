@@ -0,0 +1,43 @@
+package minify
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// unusedImports returns the imports of files whose package is not referred
+// to in files, which are the same in the minified source, as minifying does
+// not remove references. Blank and dot imports, which are imported for
+// their side effects or their exported names, the import of "C", and imports
+// of packages that could not be type-checked are never unused.
+func unusedImports(info *types.Info, files []*ast.File) map[*ast.ImportSpec]bool {
+	used := map[*types.PkgName]bool{}
+	for _, obj := range info.Uses {
+		if pkg, ok := obj.(*types.PkgName); ok {
+			used[pkg] = true
+		}
+	}
+
+	unused := map[*ast.ImportSpec]bool{}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			if isCgoImport(spec) || (spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".")) {
+				continue
+			}
+
+			var obj types.Object
+			if spec.Name != nil {
+				obj = info.Defs[spec.Name]
+			} else {
+				obj = info.Implicits[spec]
+			}
+			// the name of a package that could not be imported is only a guess
+			pkg, ok := obj.(*types.PkgName)
+			if !ok || !pkg.Imported().Complete() || used[pkg] {
+				continue
+			}
+			unused[spec] = true
+		}
+	}
+	return unused
+}
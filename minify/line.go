@@ -0,0 +1,58 @@
+package minify
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+)
+
+// lineDirective returns a line directive that sets the position of the code
+// following it to pos, if Options.LineDirectives is set and pos is on another
+// line than the previous directive.
+// Only the first directive names the file, as directives with an empty file
+// name keep the file name of the previous one.
+func (m *mingo) lineDirective(pos token.Pos) string {
+	if !m.options.LineDirectives || !pos.IsValid() {
+		return ""
+	}
+
+	p := m.fileSet.Position(pos)
+	if p.Line == m.line {
+		return ""
+	}
+	m.line = p.Line
+
+	filename := ""
+	if !m.lineFile {
+		// relative file names are relative to the directory of the minified file
+		filename = filepath.Base(p.Filename)
+		if m.options.LineDirectivesDir != "" {
+			if rel, ok := relPath(m.options.LineDirectivesDir, p.Filename); ok {
+				filename = filepath.ToSlash(rel)
+			}
+		}
+		m.lineFile = true
+	}
+	return fmt.Sprintf("/*line %s:%d:%d*/", filename, p.Line, p.Column)
+}
+
+// relPath returns the path of target relative to the directory dir,
+// either of which may be relative to the current directory.
+func relPath(dir, target string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, target)
+	return rel, err == nil
+}
+
+// stringifyPos returns the line directive and the source map mark of the
+// declaration or statement starting at pos.
+func (m *mingo) stringifyPos(pos token.Pos) string {
+	return m.lineDirective(pos) + m.mark(pos, "")
+}
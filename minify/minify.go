@@ -0,0 +1,369 @@
+// Package minify minifies Go source code.
+package minify
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Options configures a Minifier.
+// The zero value minifies with the default settings.
+type Options struct {
+	// RenameLocals renames function-local variables, parameters,
+	// named results and labels to the shortest names that do not
+	// change the meaning of any identifier in their scope.
+	RenameLocals bool
+
+	// RenameGlobals renames unexported package-level functions, types,
+	// variables and constants, consistently across all files of a package.
+	// Unexported methods and fields are renamed as well when they cannot be
+	// reached through an interface or reflection; likewise, types whose values
+	// may be inspected through reflection keep their names, and so do
+	// objects named by go:linkname and cgo export directives. Names declared
+	// more than once, such as in files for different platforms, are kept.
+	// It only takes effect in MinifyPackage.
+	RenameGlobals bool
+
+	// Verify type-checks both the original and the minified source and
+	// returns a *VerifyError if their exported APIs differ: the set of
+	// exported objects, their types and signatures, the method sets of
+	// exported types, or the values of exported constants.
+	Verify bool
+
+	// Tests controls how test files are minified.
+	// The file names of test files end in _test.go.
+	Tests TestsMode
+
+	// SkipGenerated leaves generated files, as reported by ast.IsGenerated,
+	// unchanged, the same way as TestsSkip leaves test files unchanged.
+	// Otherwise, the "Code generated ... DO NOT EDIT." comment of generated
+	// files is kept.
+	SkipGenerated bool
+
+	// KeepHeader keeps the first comment group of a file that is not only
+	// made of directives, such as a license header, if it comes before the
+	// package clause.
+	KeepHeader bool
+
+	// MinifyCgoPreamble removes the indentation and blank lines of the C code
+	// in the preamble of cgo files, which is otherwise kept as is.
+	MinifyCgoPreamble bool
+
+	// LineDirectives emits a /*line*/ directive before each declaration and
+	// each statement that starts on a new line of the original file, so that
+	// compiler errors, panics and profiles refer to positions in the original
+	// file rather than to a column of a single line.
+	// The directives name the base name of the original file, unless
+	// LineDirectivesDir is set.
+	LineDirectives bool
+
+	// LineDirectivesDir is the directory the minified files are written to,
+	// if not the directory of the original files. The line directives then
+	// name the original file by its path relative to it, as the toolchain
+	// resolves relative file names from the directory of the minified file.
+	LineDirectivesDir string
+
+	// RemoveUnusedImports drops the imports whose package is not referred
+	// to, as found by type-checking, except blank and dot imports, which are
+	// imported for their side effects or their exported names.
+	// Minifying does not remove references, so this only changes files that
+	// do not compile because of unused imports, such as work in progress or
+	// generated code; it cleans them up rather than making output smaller.
+	RemoveUnusedImports bool
+
+	// RenameMap seeds the renaming passes with the renames of a previous
+	// run, as returned by MinifyPackageResult. Identifiers with the same package
+	// directory, package name, kind, scope and name as in the previous run
+	// keep their previous names, as long as they remain valid, so that small
+	// changes to the source cause small changes to the output.
+	RenameMap []Rename
+
+	// SourceMaps builds a source map of each minified file, returned by
+	// MinifyPackageResult. It makes minifying slower.
+	SourceMaps bool
+
+	// KeepComments keeps the comments before the package clause that match
+	// any of the regular expressions, such as SPDX-License-Identifier.
+	KeepComments []*regexp.Regexp
+}
+
+// Minifier minifies Go source files.
+// A Minifier is safe for concurrent use by multiple goroutines.
+type Minifier struct {
+	options Options
+}
+
+// New returns a Minifier configured with opts.
+func New(opts Options) *Minifier {
+	return &Minifier{options: opts}
+}
+
+// Minify minifies src with the default options.
+// It is a shorthand for New(Options{}).MinifyFile(filename, src).
+func Minify(filename string, src []byte) ([]byte, error) {
+	return New(Options{}).MinifyFile(filename, src)
+}
+
+// MinifyFile minifies the Go source file filename.
+// If src is nil, the source is read from filename.
+func (mn *Minifier) MinifyFile(filename string, src []byte) ([]byte, error) {
+	if src == nil {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		src = b
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if mn.Skips(fset, file) {
+		return src, nil
+	}
+
+	return mn.MinifyAST(fset, file)
+}
+
+// MinifyReader reads Go source from r and writes the minified source to w.
+func (mn *Minifier) MinifyReader(r io.Reader, w io.Writer) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	min, err := mn.MinifyFile("<input>", src)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(min)
+	return err
+}
+
+// MinifyAST minifies an already parsed file.
+// The file must have been parsed with parser.ParseComments for directives to be preserved.
+func (mn *Minifier) MinifyAST(fset *token.FileSet, file *ast.File) ([]byte, error) {
+	res, err := mn.minify(fset, []*ast.File{file}, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return res.Files[0], nil
+}
+
+// MinifyPackage minifies the files of a single package and returns
+// the minified source of each file, in the same order as files.
+// The files are type-checked together, so renaming passes can resolve
+// identifiers declared in other files of the package.
+// RenameGlobals requires files to contain every file of the package.
+func (mn *Minifier) MinifyPackage(fset *token.FileSet, files []*ast.File) ([][]byte, error) {
+	res, err := mn.minify(fset, files, true, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return res.Files, nil
+}
+
+// PackageResult is the result of MinifyPackageResult.
+type PackageResult struct {
+	// Files holds the minified source of each file, in the same order as the files.
+	Files [][]byte
+
+	// SourceMaps holds a source map of each minified file, or nil for the
+	// files that are left unchanged. It is nil unless Options.SourceMaps is set.
+	SourceMaps []*SourceMap
+
+	// Renames holds the identifiers renamed in the files, in the order of
+	// their declarations.
+	Renames []Rename
+}
+
+// MinifyPackageResult is like MinifyPackage, but also returns the identifiers
+// renamed in files and, with Options.SourceMaps, a source map of each file.
+func (mn *Minifier) MinifyPackageResult(fset *token.FileSet, files []*ast.File) (*PackageResult, error) {
+	return mn.minify(fset, files, true, mn.options.SourceMaps, true)
+}
+
+func (mn *Minifier) minify(fset *token.FileSet, files []*ast.File, whole, sourceMaps, listRenames bool) (*PackageResult, error) {
+	globals := mn.options.RenameGlobals && whole
+
+	var (
+		pkg     *types.Package
+		info    *types.Info
+		typeErr error
+		renames map[*ast.Ident]string
+		unused  map[*ast.ImportSpec]bool
+	)
+	if mn.options.RenameLocals || globals || mn.options.RemoveUnusedImports {
+		pkg, info, typeErr = typeCheck(fset, files)
+	}
+	res := &PackageResult{Files: make([][]byte, len(files))}
+	if sourceMaps {
+		res.SourceMaps = make([]*SourceMap, len(files))
+	}
+	if r := mn.rename(fset, files, pkg, info, typeErr == nil, globals); r != nil {
+		renames = r.renames
+		if listRenames {
+			res.Renames = r.list(fset)
+		}
+	}
+	if mn.options.RemoveUnusedImports {
+		unused = unusedImports(info, files)
+	}
+
+	for i, file := range files {
+		if mn.Skips(fset, file) {
+			b := new(bytes.Buffer)
+			if err := format.Node(b, fset, file); err != nil {
+				return nil, err
+			}
+			res.Files[i] = b.Bytes()
+			continue
+		}
+
+		m := &mingo{fileSet: fset, options: mn.options, renames: renames, unusedImports: unused, sourceMap: sourceMaps}
+		min, err := m.Minify(file)
+		if err != nil {
+			return nil, err
+		}
+		if sourceMaps {
+			min, res.SourceMaps[i] = m.buildSourceMap(fset.Position(file.Pos()).Filename, min)
+		}
+		res.Files[i] = min
+	}
+
+	if mn.options.Verify {
+		if err := verify(fset, files, res.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// rename runs the renaming passes enabled by the options over files, whose
+// package is pkg, or returns nil if there are none. Types, methods and
+// fields are only renamed if typed, that is, if the package type-checks.
+func (mn *Minifier) rename(fset *token.FileSet, files []*ast.File, pkg *types.Package, info *types.Info, typed, globals bool) *renamer {
+	if !mn.options.RenameLocals && !globals {
+		return nil
+	}
+
+	r := newRenamer(info, files)
+	for _, file := range files {
+		if mn.Skips(fset, file) {
+			r.keepReferenced(file)
+		}
+	}
+	r.keepDirectiveNames(pkg)
+	if mn.options.RenameMap != nil {
+		r.seed(fset, mn.options.RenameMap)
+	}
+	if globals {
+		r.renameGlobals(pkg, typed)
+	}
+	if mn.options.RenameLocals {
+		r.renameLocals()
+	}
+	return r
+}
+
+// Skips reports whether file is left unchanged, because it is a test file
+// and Tests is TestsSkip, or a generated file and SkipGenerated is set.
+func (mn *Minifier) Skips(fset *token.FileSet, file *ast.File) bool {
+	if mn.options.Tests == TestsSkip && isTestFile(fset, file) {
+		return true
+	}
+	return mn.options.SkipGenerated && ast.IsGenerated(file)
+}
+
+// UnsupportedNodeError is returned when a file contains syntax that cannot be minified,
+// such as the *ast.Bad* nodes produced for source with syntax errors.
+type UnsupportedNodeError struct {
+	// Pos is the position of the node.
+	Pos token.Position
+	// Kind is the name of the node type, e.g. "BadExpr".
+	Kind string
+}
+
+func (e *UnsupportedNodeError) Error() string {
+	return fmt.Sprintf("%s: unsupported syntax: %s", e.Pos, e.Kind)
+}
+
+type mingo struct {
+	fileSet *token.FileSet
+	options Options
+	renames map[*ast.Ident]string
+	// unusedImports holds the imports dropped by Options.RemoveUnusedImports.
+	unusedImports map[*ast.ImportSpec]bool
+
+	// directives maps declarations and specs to the directives preceding them.
+	directives map[ast.Node][]*ast.Comment
+
+	// line is the line of the last line directive, and lineFile is set once
+	// a line directive has named the file.
+	line     int
+	lineFile bool
+
+	// sourceMap is set if marks are recorded for a source map.
+	sourceMap bool
+	marks     []mark
+
+	// examples maps example functions to the output comments to keep.
+	examples map[*ast.FuncDecl]*ast.CommentGroup
+
+	// err is the first error encountered while stringifying.
+	err error
+}
+
+// unsupported records an UnsupportedNodeError for n.
+// It returns an empty string so that callers can return it in place of the stringified node.
+func (m *mingo) unsupported(n ast.Node) string {
+	if m.err == nil {
+		m.err = &UnsupportedNodeError{
+			Pos:  m.fileSet.Position(n.Pos()),
+			Kind: strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."),
+		}
+	}
+	return ""
+}
+
+func (m *mingo) Minify(file *ast.File) ([]byte, error) {
+	b := new(bytes.Buffer)
+
+	if m.options.Tests == TestsPreserveExamples && isTestFile(m.fileSet, file) {
+		m.examples = map[*ast.FuncDecl]*ast.CommentGroup{}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if cg := exampleOutput(fn, file.Comments); cg != nil {
+					m.examples[fn] = cg
+				}
+			}
+		}
+	}
+
+	m.attachDirectives(file)
+
+	fmt.Fprint(b, m.stringifyHeader(file))
+	fmt.Fprint(b, m.stringifyFile(file))
+	for _, decl := range file.Decls {
+		fmt.Fprint(b, m.stringifyDecl(decl))
+	}
+	fmt.Fprint(b, m.stringifyDirectives(file))
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return b.Bytes(), nil
+}
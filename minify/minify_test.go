@@ -0,0 +1,232 @@
+package minify
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func Test_Minify(t *testing.T) {
+	dirs, err := testdata.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		if dir.Name() == "packages" {
+			continue
+		}
+
+		t.Run(dir.Name(), func(t *testing.T) {
+			src, err := testdata.ReadFile(fmt.Sprintf("testdata/%s/main.go", dir.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := testdata.ReadFile(fmt.Sprintf("testdata/%s/expected", dir.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := readOptions(t, fmt.Sprintf("testdata/%s", dir.Name()))
+
+			got, err := New(opts).MinifyFile("main.go", src)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+
+			// check syntax
+			_, err = format.Source([]byte(got))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_MinifyPackage(t *testing.T) {
+	dirs, err := testdata.ReadDir("testdata/packages")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		t.Run(dir.Name(), func(t *testing.T) {
+			root := fmt.Sprintf("testdata/packages/%s", dir.Name())
+			entries, err := testdata.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fset := token.NewFileSet()
+			var files []*ast.File
+			for _, entry := range entries {
+				if filepath.Ext(entry.Name()) != ".go" {
+					continue
+				}
+				src, err := testdata.ReadFile(fmt.Sprintf("%s/%s", root, entry.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				file, err := parser.ParseFile(fset, entry.Name(), src, parser.ParseComments)
+				if err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, file)
+			}
+
+			got, err := New(readOptions(t, root)).MinifyPackage(fset, files)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			minFset := token.NewFileSet()
+			var minFiles []*ast.File
+			for i, file := range files {
+				name := fset.Position(file.Pos()).Filename
+				want, err := testdata.ReadFile(fmt.Sprintf("%s/%s.expected", root, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, string(want), string(got[i]), name)
+
+				minFile, err := parser.ParseFile(minFset, name, got[i], 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				minFiles = append(minFiles, minFile)
+			}
+
+			// check that the minified package still compiles on each platform
+			for _, goos := range []string{"linux", "windows"} {
+				ctxt := build.Default
+				ctxt.GOOS = goos
+				ctxt.JoinPath = path.Join
+				ctxt.OpenFile = func(name string) (io.ReadCloser, error) { return testdata.Open(name) }
+
+				var pkgFiles []*ast.File
+				for _, file := range minFiles {
+					ok, err := ctxt.MatchFile(root, minFset.Position(file.Pos()).Filename)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if ok {
+						pkgFiles = append(pkgFiles, file)
+					}
+				}
+
+				conf := &types.Config{Importer: importer.Default()}
+				_, err = conf.Check(files[0].Name.Name, minFset, pkgFiles, nil)
+				assert.NoError(t, err, goos)
+			}
+		})
+	}
+}
+
+func readOptions(t *testing.T, dir string) Options {
+	var opts Options
+	if b, err := testdata.ReadFile(fmt.Sprintf("%s/options.json", dir)); err == nil {
+		if err := json.Unmarshal(b, &opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return opts
+}
+
+func Test_MinifyReader(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+
+	b := new(bytes.Buffer)
+	err := New(Options{}).MinifyReader(strings.NewReader(src), b)
+	assert.NoError(t, err)
+	assert.Equal(t, `package main;func main(){println("hello")};`, b.String())
+}
+
+func Test_MinifyFile_skipGenerated(t *testing.T) {
+	src := "// Code generated by hand. DO NOT EDIT.\n\npackage main\n\nfunc main() {\n}\n"
+
+	got, err := New(Options{SkipGenerated: true}).MinifyFile("main.go", []byte(src))
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(got))
+}
+
+func Test_MinifyAST(t *testing.T) {
+	src := "package main\n\nvar x = 1\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := New(Options{}).MinifyAST(fset, file)
+	assert.NoError(t, err)
+	assert.Equal(t, "package main;var x=1;", string(got))
+}
+
+func Test_MinifyAST_unsupported(t *testing.T) {
+	src := "package main\n\nvar x = 1\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	spec.Values[0] = &ast.BadExpr{From: spec.Values[0].Pos(), To: spec.Values[0].End()}
+
+	_, err = New(Options{}).MinifyAST(fset, file)
+
+	var uerr *UnsupportedNodeError
+	if assert.ErrorAs(t, err, &uerr) {
+		assert.Equal(t, "BadExpr", uerr.Kind)
+		assert.Equal(t, "main.go:3:9", uerr.Pos.String())
+	}
+}
+
+func Test_stringifyChanType(t *testing.T) {
+	m := &mingo{fileSet: token.NewFileSet()}
+
+	// chan (<-chan int) without an explicit *ast.ParenExpr
+	expr := &ast.ChanType{
+		Dir:   ast.SEND | ast.RECV,
+		Value: &ast.ChanType{Dir: ast.RECV, Value: ast.NewIdent("int")},
+	}
+	assert.Equal(t, "chan (<-chan int)", m.stringifyChanType(expr))
+}
+
+func Test_verify(t *testing.T) {
+	src := "package p\n\nconst Size = 4\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, verify(fset, []*ast.File{file}, [][]byte{[]byte("package p;const Size=4;func Add(x,y int)int{return x+y};")}))
+
+	err = verify(fset, []*ast.File{file}, [][]byte{[]byte("package p;const Size=8;func Add(a,b int64)int64{return a+b};")})
+	var verr *VerifyError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, []string{
+			"Add: func(int64,int64)(int64), want func(int,int)(int)",
+			"Size: const untyped int = 8, want const untyped int = 4",
+		}, verr.Diffs)
+	}
+}
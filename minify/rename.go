@@ -0,0 +1,587 @@
+package minify
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// typeCheck type-checks files as a single package and returns the first type error.
+// Type errors, such as imports that cannot be resolved, do not stop the check:
+// renaming of lexically scoped identifiers only relies on scope information,
+// which is recorded even for packages that do not fully type-check.
+func typeCheck(fset *token.FileSet, files []*ast.File) (*types.Package, *types.Info, error) {
+	info := &types.Info{
+		Types:     map[ast.Expr]types.TypeAndValue{},
+		Defs:      map[*ast.Ident]types.Object{},
+		Uses:      map[*ast.Ident]types.Object{},
+		Implicits: map[ast.Node]types.Object{},
+		Scopes:    map[ast.Node]*types.Scope{},
+	}
+	var first error
+	conf := &types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			if first == nil {
+				first = err
+			}
+		},
+	}
+
+	path := ""
+	if len(files) > 0 {
+		path = files[0].Name.Name
+	}
+	pkg, _ := conf.Check(path, fset, files, info)
+
+	return pkg, info, first
+}
+
+// renameUnit is a set of objects that must be renamed together,
+// such as the implicit per-clause objects of a type switch symbol.
+type renameUnit struct {
+	objs   []types.Object
+	idents []*ast.Ident
+
+	// seed is the name of the unit in a previous run, if any.
+	seed string
+}
+
+func (u *renameUnit) name() string {
+	return u.objs[0].Name()
+}
+
+func (u *renameUnit) has(obj types.Object) bool {
+	for _, o := range u.objs {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
+type renamer struct {
+	info  *types.Info
+	files []*ast.File
+
+	// idents are all identifiers that are resolved lexically, in source order.
+	idents []*ast.Ident
+	// symbols maps type switch symbols to the unit of their implicit objects.
+	symbols map[*ast.Ident]*renameUnit
+	// uses maps objects to the identifiers that refer to them.
+	uses map[types.Object][]*ast.Ident
+
+	// keep holds the objects that must not be renamed.
+	keep map[types.Object]bool
+
+	names   map[types.Object]string
+	renames map[*ast.Ident]string
+
+	// seeds holds the names of a previous run that are not taken yet, by identifier,
+	// whose keys are computed with the positions of fset.
+	seeds map[renameKey][]string
+	fset  *token.FileSet
+}
+
+func newRenamer(info *types.Info, files []*ast.File) *renamer {
+	r := &renamer{
+		info:    info,
+		files:   files,
+		symbols: map[*ast.Ident]*renameUnit{},
+		uses:    map[types.Object][]*ast.Ident{},
+		keep:    map[types.Object]bool{},
+		names:   map[types.Object]string{},
+		renames: map[*ast.Ident]string{},
+	}
+
+	for _, file := range files {
+		sels := map[*ast.Ident]bool{}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.SelectorExpr:
+				sels[x.Sel] = true
+			case *ast.Ident:
+				if !sels[x] {
+					r.idents = append(r.idents, x)
+				}
+			}
+			return true
+		})
+	}
+	sort.Slice(r.idents, func(i, j int) bool { return r.idents[i].Pos() < r.idents[j].Pos() })
+
+	for id, obj := range info.Uses {
+		obj = origin(obj)
+		r.uses[obj] = append(r.uses[obj], id)
+	}
+	for id, obj := range info.Defs {
+		if obj != nil {
+			r.uses[obj] = append(r.uses[obj], id)
+		}
+	}
+
+	return r
+}
+
+// keepReferenced keeps the names of the objects declared or referred to in
+// file, which is not rewritten.
+func (r *renamer) keepReferenced(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj := r.objectOf(id); obj != nil {
+				r.keep[origin(obj)] = true
+			}
+		}
+		return true
+	})
+}
+
+// renameLocals renames function-local variables, parameters, named results
+// and labels to the shortest names that do not change the meaning of any
+// identifier in their scope.
+func (r *renamer) renameLocals() {
+	var units []*renameUnit
+	seen := map[types.Object]bool{}
+
+	for _, file := range r.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			sw, ok := n.(*ast.TypeSwitchStmt)
+			if !ok {
+				return true
+			}
+			assign, ok := sw.Assign.(*ast.AssignStmt)
+			if !ok {
+				return true
+			}
+			symbol := assign.Lhs[0].(*ast.Ident)
+
+			u := &renameUnit{idents: []*ast.Ident{symbol}}
+			for _, clause := range sw.Body.List {
+				if obj := r.info.Implicits[clause]; obj != nil {
+					u.objs = append(u.objs, obj)
+					seen[obj] = true
+				}
+			}
+			if len(u.objs) > 0 && symbol.Name != "_" {
+				r.symbols[symbol] = u
+				units = append(units, u)
+			}
+			return true
+		})
+	}
+
+	var walk func(s *types.Scope)
+	walk = func(s *types.Scope) {
+		for _, name := range s.Names() {
+			obj, ok := s.Lookup(name).(*types.Var)
+			if !ok || obj.IsField() || obj.Name() == "_" || seen[obj] {
+				continue
+			}
+			seen[obj] = true
+			units = append(units, &renameUnit{objs: []types.Object{obj}})
+		}
+		for i := 0; i < s.NumChildren(); i++ {
+			walk(s.Child(i))
+		}
+	}
+	for _, file := range r.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			var typ *ast.FuncType
+			switch x := n.(type) {
+			case *ast.FuncDecl:
+				typ = x.Type
+			case *ast.FuncLit:
+				typ = x.Type
+			default:
+				return true
+			}
+			if s, ok := r.info.Scopes[typ]; ok {
+				walk(s)
+			}
+			return true
+		})
+	}
+
+	for _, u := range units {
+		for _, obj := range u.objs {
+			u.idents = append(u.idents, r.uses[obj]...)
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].objs[0].Pos() < units[j].objs[0].Pos() })
+
+	r.seedUnits(units)
+	for _, u := range units {
+		r.renameUnit(u)
+	}
+	// units whose previous names are no longer valid are renamed last
+	for _, u := range units {
+		if !r.renamed(u) {
+			r.renameUnit(u)
+		}
+	}
+
+	r.renameLabels()
+}
+
+// renameGlobals renames unexported package-level identifiers, and the
+// unexported methods and fields that cannot be observed through interfaces
+// or reflection, to names that are not used anywhere else in the package.
+// Types, methods and fields are only renamed if the package type-checks.
+func (r *renamer) renameGlobals(pkg *types.Package, typed bool) {
+	used := map[string]bool{}
+	for _, file := range r.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+			return true
+		})
+	}
+
+	var escaping map[*types.TypeName]bool
+	ifaceMethods := map[string]bool{}
+	if typed {
+		escaping = escapingTypes(pkg, r.info, r.files)
+		for _, tv := range r.info.Types {
+			if iface, ok := coreType(tv.Type).(*types.Interface); ok {
+				for i := 0; i < iface.NumMethods(); i++ {
+					ifaceMethods[iface.Method(i).Name()] = true
+				}
+			}
+		}
+	}
+
+	// names declared more than once, such as functions implemented in a file
+	// per platform, keep their names: only one of the declarations is in
+	// scope, so the others would not be renamed along with their uses
+	declared := map[string]int{}
+	for _, file := range r.files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					declared[decl.Name.Name]++
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							declared[name.Name]++
+						}
+					case *ast.TypeSpec:
+						declared[spec.Name.Name]++
+					}
+				}
+			}
+		}
+	}
+
+	scope := pkg.Scope()
+	isGlobal := func(id *ast.Ident) (types.Object, bool) {
+		obj := r.info.Defs[id]
+		if obj == nil || obj.Exported() || obj.Name() == "_" || scope.Lookup(obj.Name()) != obj || declared[obj.Name()] > 1 {
+			return nil, false
+		}
+		return obj, true
+	}
+
+	var units []*renameUnit
+	add := func(objs ...types.Object) {
+		for _, obj := range objs {
+			if r.keep[obj] {
+				return
+			}
+		}
+		u := &renameUnit{objs: objs}
+		for _, obj := range objs {
+			u.idents = append(u.idents, r.uses[obj]...)
+		}
+		units = append(units, u)
+	}
+
+	for _, file := range r.files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					if decl.Name.Name == "init" || (decl.Name.Name == "main" && pkg.Name() == "main") {
+						continue
+					}
+					if obj, ok := isGlobal(decl.Name); ok {
+						add(obj)
+					}
+					continue
+				}
+				obj := r.info.Defs[decl.Name]
+				if typed && obj != nil && !obj.Exported() && decl.Name.Name != "_" && !ifaceMethods[obj.Name()] {
+					add(obj)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if obj, ok := isGlobal(name); ok {
+								add(obj)
+							}
+						}
+					case *ast.TypeSpec:
+						r.addTypeSpec(spec, typed, escaping, isGlobal, add)
+					}
+				}
+			}
+		}
+	}
+
+	r.seedUnits(units)
+	for _, u := range units {
+		// an upper case name would export the identifier
+		if u.seed != "" && !used[u.seed] && !token.IsExported(u.seed) {
+			used[u.seed] = true
+			r.assign(u, u.seed)
+		}
+	}
+	// the previous names of the other identifiers, such as locals, are
+	// reserved so that new globals do not take them
+	for _, names := range r.seeds {
+		for _, name := range names {
+			used[name] = true
+		}
+	}
+	for _, u := range units {
+		if r.renamed(u) {
+			continue
+		}
+		for i := 0; ; i++ {
+			name := shortName(i)
+			if len(name) >= len(u.name()) {
+				break
+			}
+			if used[name] || token.IsKeyword(name) || token.IsExported(name) {
+				continue
+			}
+
+			used[name] = true
+			r.assign(u, name)
+			break
+		}
+	}
+}
+
+// addTypeSpec adds the type declared by spec, and the fields of its struct
+// type, if their names cannot be observed at run time.
+func (r *renamer) addTypeSpec(spec *ast.TypeSpec, typed bool, escaping map[*types.TypeName]bool, isGlobal func(*ast.Ident) (types.Object, bool), add func(...types.Object)) {
+	obj, ok := r.info.Defs[spec.Name].(*types.TypeName)
+	if !typed || !ok || escaping[obj] || spec.TypeParams != nil {
+		return
+	}
+
+	if _, ok := isGlobal(spec.Name); ok {
+		// embedded fields are named after their type
+		objs := []types.Object{obj}
+		for id, def := range r.info.Defs {
+			if field, ok := def.(*types.Var); ok && field.Embedded() && r.info.Uses[id] == obj {
+				objs = append(objs, field)
+			}
+		}
+		add(objs...)
+	}
+
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || r.hasIdenticalStruct(obj.Type().Underlying()) {
+		return
+	}
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if f := r.info.Defs[name]; f != nil && !f.Exported() && f.Name() != "_" {
+				add(f)
+			}
+		}
+	}
+}
+
+// hasIdenticalStruct reports whether any other struct type in the package is
+// identical to t, ignoring tags. Such types are convertible to each other only
+// as long as their field names match.
+func (r *renamer) hasIdenticalStruct(t types.Type) bool {
+	for _, tv := range r.info.Types {
+		if u := coreType(tv.Type); u != t {
+			if _, ok := u.(*types.Struct); ok && types.IdenticalIgnoreTags(u, t) {
+				return true
+			}
+		}
+	}
+	for _, obj := range r.info.Defs {
+		if tn, ok := obj.(*types.TypeName); ok {
+			if u := coreType(tn.Type()); u != t {
+				if _, ok := u.(*types.Struct); ok && types.IdenticalIgnoreTags(u, t) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// renameUnit renames u to the first candidate name that is not referenced
+// by any identifier in the scopes of its objects.
+func (r *renamer) renameUnit(u *renameUnit) {
+	forbidden := map[string]bool{}
+	for _, obj := range u.objs {
+		s := obj.Parent()
+		if s == nil || !s.Pos().IsValid() {
+			return
+		}
+		for _, id := range r.identsIn(s.Pos(), s.End()) {
+			other := r.objectOf(id)
+			switch {
+			case other == nil:
+				if id.Name == u.name() {
+					// an unresolved reference may refer to the object; leave it alone
+					return
+				}
+				forbidden[id.Name] = true
+			case u.has(other):
+			case other.Parent() == nil:
+			default:
+				if _, ok := other.(*types.Label); ok {
+					continue
+				}
+				forbidden[r.nameOf(other)] = true
+			}
+		}
+	}
+
+	r.rename(u, forbidden)
+}
+
+func (r *renamer) renameLabels() {
+	for _, file := range r.files {
+		for _, decl := range file.Decls {
+			var units []*renameUnit
+			ast.Inspect(decl, func(n ast.Node) bool {
+				if stmt, ok := n.(*ast.LabeledStmt); ok {
+					if obj := r.info.Defs[stmt.Label]; obj != nil {
+						units = append(units, &renameUnit{objs: []types.Object{obj}, idents: r.uses[obj]})
+					}
+				}
+				return true
+			})
+
+			r.seedUnits(units)
+			rename := func(u *renameUnit) {
+				forbidden := map[string]bool{}
+				for _, other := range units {
+					if other != u {
+						forbidden[r.nameOf(other.objs[0])] = true
+					}
+				}
+				r.rename(u, forbidden)
+			}
+			for _, u := range units {
+				rename(u)
+			}
+			for _, u := range units {
+				if !r.renamed(u) {
+					rename(u)
+				}
+			}
+		}
+	}
+}
+
+func (r *renamer) rename(u *renameUnit, forbidden map[string]bool) {
+	// a unit whose previous name is no longer valid is left for a later
+	// call, once the other units have taken their previous names
+	if u.seed != "" {
+		seed := u.seed
+		u.seed = ""
+		if !forbidden[seed] {
+			r.assign(u, seed)
+		}
+		return
+	}
+
+	for i := 0; ; i++ {
+		name := shortName(i)
+		if len(name) >= len(u.name()) {
+			return
+		}
+		if forbidden[name] || token.IsKeyword(name) {
+			continue
+		}
+
+		r.assign(u, name)
+		return
+	}
+}
+
+func (r *renamer) renamed(u *renameUnit) bool {
+	_, ok := r.names[u.objs[0]]
+	return ok
+}
+
+func (r *renamer) assign(u *renameUnit, name string) {
+	for _, obj := range u.objs {
+		r.names[obj] = name
+	}
+	for _, id := range u.idents {
+		r.renames[id] = name
+	}
+}
+
+// identsIn returns the lexically resolved identifiers in [pos, end).
+func (r *renamer) identsIn(pos, end token.Pos) []*ast.Ident {
+	i := sort.Search(len(r.idents), func(i int) bool { return r.idents[i].Pos() >= pos })
+	j := sort.Search(len(r.idents), func(i int) bool { return r.idents[i].Pos() >= end })
+	return r.idents[i:j]
+}
+
+func (r *renamer) objectOf(id *ast.Ident) types.Object {
+	if u, ok := r.symbols[id]; ok {
+		return u.objs[0]
+	}
+	if obj := r.info.Uses[id]; obj != nil {
+		return obj
+	}
+	if obj := r.info.Defs[id]; obj != nil {
+		return obj
+	}
+	return nil
+}
+
+func (r *renamer) nameOf(obj types.Object) string {
+	if name, ok := r.names[origin(obj)]; ok {
+		return name
+	}
+	return obj.Name()
+}
+
+// origin returns the generic object obj was instantiated from, or obj itself.
+func origin(obj types.Object) types.Object {
+	switch x := obj.(type) {
+	case *types.Var:
+		return x.Origin()
+	case *types.Func:
+		return x.Origin()
+	}
+	return obj
+}
+
+const nameChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// shortName returns the i-th identifier in the sequence a, b, ..., Z, aa, ab, ...
+func shortName(i int) string {
+	n := len(nameChars)
+	b := []byte{}
+	for {
+		b = append([]byte{nameChars[i%n]}, b...)
+		i = i/n - 1
+		if i < 0 {
+			return string(b)
+		}
+	}
+}
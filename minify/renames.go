@@ -0,0 +1,201 @@
+package minify
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/koki-develop/mingo/internal/astutil"
+)
+
+// Rename describes an identifier renamed by a renaming pass.
+type Rename struct {
+	// Package is the name of the package declaring the identifier.
+	Package string `json:"package"`
+	// Kind is one of "const", "var", "func", "method", "type", "field" and "label".
+	Kind string `json:"kind"`
+	// Scope names the declaration the identifier belongs to: the function,
+	// as "f" or "T.m", declaring a local variable or a label, the type
+	// declaring a field or a method, or the variable initialized by a
+	// function literal declaring a local variable. It is empty for
+	// package-level identifiers.
+	Scope string `json:"scope,omitempty"`
+	// Name is the original name.
+	Name string `json:"name"`
+	// NewName is the name in the minified source.
+	NewName string `json:"new_name"`
+	// Pos is the position of the declaration, as "file:line:column".
+	Pos string `json:"pos"`
+}
+
+// renameKey identifies an identifier across runs, independently of its position:
+// by the directory and the name of its package, its kind, scope and name.
+type renameKey struct {
+	dir, pkg, kind, scope, name string
+}
+
+func (rn Rename) key() renameKey {
+	// strip the line and column from the position
+	filename := rn.Pos
+	for i := 0; i < 2; i++ {
+		j := strings.LastIndexByte(filename, ':')
+		if j < 0 {
+			break
+		}
+		if _, err := strconv.Atoi(filename[j+1:]); err != nil {
+			break
+		}
+		filename = filename[:j]
+	}
+	return renameKey{dir: filepath.Dir(filename), pkg: rn.Package, kind: rn.Kind, scope: rn.Scope, name: rn.Name}
+}
+
+func (r *renamer) keyOf(obj types.Object) renameKey {
+	return renameKey{
+		dir:   filepath.Dir(r.fset.Position(obj.Pos()).Filename),
+		pkg:   r.files[0].Name.Name,
+		kind:  kindOf(obj),
+		scope: r.scopeOf(obj),
+		name:  obj.Name(),
+	}
+}
+
+// seed records the new names of renames, from a previous run.
+func (r *renamer) seed(fset *token.FileSet, renames []Rename) {
+	r.fset = fset
+	r.seeds = map[renameKey][]string{}
+	for _, rn := range renames {
+		if token.IsIdentifier(rn.NewName) {
+			k := rn.key()
+			r.seeds[k] = append(r.seeds[k], rn.NewName)
+		}
+	}
+}
+
+// seedUnits gives units the names of the identifiers with the same key in
+// the previous run, in order, and moves the seeded units first so that the
+// others do not take their names.
+func (r *renamer) seedUnits(units []*renameUnit) {
+	if r.seeds == nil {
+		return
+	}
+	for _, u := range units {
+		k := r.keyOf(u.objs[0])
+		if names := r.seeds[k]; len(names) > 0 {
+			u.seed, r.seeds[k] = names[0], names[1:]
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].seed != "" && units[j].seed == "" })
+}
+
+// list returns the renamed objects, in the order of their declarations.
+func (r *renamer) list(fset *token.FileSet) []Rename {
+	var objs []types.Object
+	seen := map[token.Pos]bool{}
+	for obj := range r.names {
+		// the implicit objects of a type switch share the position of its symbol
+		if seen[obj.Pos()] {
+			continue
+		}
+		// embedded fields are renamed with their type
+		if v, ok := obj.(*types.Var); ok && v.Embedded() {
+			continue
+		}
+		seen[obj.Pos()] = true
+		objs = append(objs, obj)
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Pos() < objs[j].Pos() })
+
+	renames := make([]Rename, 0, len(objs))
+	for _, obj := range objs {
+		renames = append(renames, Rename{
+			Package: r.files[0].Name.Name,
+			Kind:    kindOf(obj),
+			Scope:   r.scopeOf(obj),
+			Name:    obj.Name(),
+			NewName: r.names[obj],
+			Pos:     fset.Position(obj.Pos()).String(),
+		})
+	}
+	return renames
+}
+
+func kindOf(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		if obj.IsField() {
+			return "field"
+		}
+		return "var"
+	case *types.Func:
+		if obj.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.Label:
+		return "label"
+	}
+	return ""
+}
+
+// scopeOf returns the scope of obj, as described in Rename.
+func (r *renamer) scopeOf(obj types.Object) string {
+	pos := obj.Pos()
+	for _, file := range r.files {
+		if pos < file.Pos() || pos >= file.End() {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if pos < decl.Pos() || pos >= decl.End() {
+				continue
+			}
+
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Name.Pos() != pos {
+					return funcName(decl)
+				}
+				if decl.Recv != nil {
+					return astutil.RecvTypeName(decl)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if pos < spec.Pos() || pos >= spec.End() {
+						continue
+					}
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.Pos() != pos {
+							return spec.Name.Name
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.Pos() == pos {
+								return ""
+							}
+						}
+						return spec.Names[0].Name
+					}
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// funcName returns the name of fn, as "f" or "T.m" for a method.
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil {
+		return fn.Name.Name
+	}
+	return astutil.RecvTypeName(fn) + "." + fn.Name.Name
+}
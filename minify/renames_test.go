@@ -0,0 +1,144 @@
+package minify
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MinifyPackageResult_renames(t *testing.T) {
+	src := `package main
+
+const limit = 10
+
+type counter struct {
+	total int
+}
+
+func (c *counter) add(value int) {
+	c.total += value
+}
+
+var handler = func(message string) {
+	println(message)
+}
+
+func main() {
+	var items counter
+outer:
+	for index := 0; index < limit; index++ {
+		items.add(index)
+		switch value := any(index).(type) {
+		case int:
+			_ = value
+			continue outer
+		}
+	}
+	handler("done")
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := New(Options{RenameLocals: true, RenameGlobals: true}).MinifyPackageResult(fset, []*ast.File{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Rename{
+		{Package: "main", Kind: "const", Name: "limit", NewName: "a", Pos: "main.go:3:7"},
+		{Package: "main", Kind: "type", Name: "counter", NewName: "b", Pos: "main.go:5:6"},
+		{Package: "main", Kind: "field", Scope: "counter", Name: "total", NewName: "d", Pos: "main.go:6:2"},
+		{Package: "main", Kind: "method", Scope: "counter", Name: "add", NewName: "e", Pos: "main.go:9:19"},
+		{Package: "main", Kind: "var", Scope: "counter.add", Name: "value", NewName: "a", Pos: "main.go:9:23"},
+		{Package: "main", Kind: "var", Name: "handler", NewName: "f", Pos: "main.go:13:5"},
+		{Package: "main", Kind: "var", Scope: "handler", Name: "message", NewName: "a", Pos: "main.go:13:20"},
+		{Package: "main", Kind: "var", Scope: "main", Name: "items", NewName: "c", Pos: "main.go:18:6"},
+		{Package: "main", Kind: "label", Scope: "main", Name: "outer", NewName: "a", Pos: "main.go:19:1"},
+		{Package: "main", Kind: "var", Scope: "main", Name: "index", NewName: "b", Pos: "main.go:20:6"},
+		{Package: "main", Kind: "var", Scope: "main", Name: "value", NewName: "a", Pos: "main.go:22:10"},
+	}, res.Renames)
+
+	res, err = New(Options{}).MinifyPackageResult(fset, []*ast.File{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, res.Renames)
+}
+
+func Test_MinifyPackageResult_renameMap(t *testing.T) {
+	src := `package main
+
+var greeting = "hello"
+
+func greet(name string) string {
+	message := greeting + ", " + name
+	return message
+}
+
+func main() {
+	println(greet("gopher"))
+}
+`
+	// a new function and a new local variable before the others
+	edited := `package main
+
+var greeting = "hello"
+
+func shout(text string) string {
+	return text + "!"
+}
+
+func greet(name string) string {
+	prefix := greeting
+	message := prefix + ", " + name
+	return shout(message)
+}
+
+func main() {
+	println(greet("gopher"))
+}
+`
+
+	renames := func(opts Options, src string) []Rename {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := New(opts).MinifyPackageResult(fset, []*ast.File{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Renames
+	}
+	newNames := func(renames []Rename) map[string]string {
+		names := map[string]string{}
+		for _, rn := range renames {
+			names[rn.Scope+"."+rn.Name] = rn.NewName
+		}
+		return names
+	}
+
+	opts := Options{RenameLocals: true, RenameGlobals: true}
+	prev := renames(opts, src)
+	assert.Equal(t, map[string]string{".greeting": "a", ".greet": "b", "greet.name": "c", "greet.message": "d"}, newNames(prev))
+
+	// without the previous map, the new identifiers take the names of the others
+	assert.Equal(t, map[string]string{".greeting": "a", ".shout": "b", "shout.text": "a", ".greet": "c", "greet.name": "d", "greet.prefix": "e", "greet.message": "f"}, newNames(renames(opts, edited)))
+
+	// with it, the existing identifiers keep their names, and the new ones
+	// take names that are not used by any of them
+	opts.RenameMap = prev
+	got := newNames(renames(opts, edited))
+	for key, name := range newNames(prev) {
+		assert.Equal(t, name, got[key], key)
+	}
+	assert.Equal(t, map[string]string{".greeting": "a", ".shout": "e", "shout.text": "a", ".greet": "b", "greet.name": "c", "greet.prefix": "f", "greet.message": "d"}, got)
+}
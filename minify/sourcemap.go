@@ -0,0 +1,212 @@
+package minify
+
+import (
+	"bytes"
+	"errors"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// SourceMap maps positions in a minified file to positions in its original
+// file, in the format of version 3 of the source map specification.
+// Columns are counted in bytes.
+type SourceMap struct {
+	Version int `json:"version"`
+	// File is the name of the minified file.
+	File string `json:"file"`
+	// Sources holds the name of the original file.
+	Sources []string `json:"sources"`
+	// Names holds the original names of renamed identifiers.
+	Names []string `json:"names"`
+	// Mappings holds the positions in the original file of the identifiers
+	// and basic literals of the minified file, and of the start of each
+	// declaration and statement, encoded as Base64 VLQ.
+	Mappings string `json:"mappings"`
+}
+
+// A Mapping maps a position in a minified file to a position in its original file.
+// Lines and columns are zero-based, and columns are counted in bytes.
+type Mapping struct {
+	Line, Column             int
+	SourceLine, SourceColumn int
+	// Name is the original name of a renamed identifier, if any.
+	Name string
+}
+
+// Decode returns the mappings of sm, in the order of their positions in the minified file.
+func (sm *SourceMap) Decode() ([]Mapping, error) {
+	var mappings []Mapping
+	var srcLine, srcCol, name int
+	for line, group := range strings.Split(sm.Mappings, ";") {
+		if group == "" {
+			continue
+		}
+		col := 0
+		for _, seg := range strings.Split(group, ",") {
+			var fields []int
+			for seg != "" {
+				n, rest, err := readVLQ(seg)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, n)
+				seg = rest
+			}
+			if len(fields) != 4 && len(fields) != 5 {
+				return nil, errors.New("invalid source map segment")
+			}
+
+			col += fields[0]
+			srcLine += fields[2]
+			srcCol += fields[3]
+			mp := Mapping{Line: line, Column: col, SourceLine: srcLine, SourceColumn: srcCol}
+			if len(fields) == 5 {
+				name += fields[4]
+				if name < 0 || name >= len(sm.Names) {
+					return nil, errors.New("invalid source map name index")
+				}
+				mp.Name = sm.Names[name]
+			}
+			mappings = append(mappings, mp)
+		}
+	}
+	return mappings, nil
+}
+
+// A mark records the original position of the code following it in the
+// stringified source, and the original name of a renamed identifier.
+// The stringified source refers to marks by index, between NUL characters,
+// which cannot occur in Go source.
+type mark struct {
+	pos  token.Pos
+	name string
+}
+
+const markDelim = '\x00'
+
+// mark returns a reference to the position pos if a source map is recorded.
+func (m *mingo) mark(pos token.Pos, name string) string {
+	if !m.sourceMap || !pos.IsValid() {
+		return ""
+	}
+	// an identifier starting a statement is already marked, right before it
+	if n := len(m.marks); n > 0 && m.marks[n-1].pos == pos {
+		if name != "" {
+			m.marks[n-1].name = name
+		}
+		return ""
+	}
+	m.marks = append(m.marks, mark{pos: pos, name: name})
+	return string(markDelim) + strconv.Itoa(len(m.marks)-1) + string(markDelim)
+}
+
+// buildSourceMap removes the marks from b and returns the source map they describe.
+func (m *mingo) buildSourceMap(filename string, b []byte) ([]byte, *SourceMap) {
+	sm := &SourceMap{Version: 3, File: filename, Sources: []string{filename}, Names: []string{}}
+	names := map[string]int{}
+
+	out := make([]byte, 0, len(b))
+	mappings := new(strings.Builder)
+	var (
+		col, prevCol         int
+		prevLine, prevSrcCol int
+		prevName             int
+		segments             int
+	)
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, markDelim)
+		if i < 0 {
+			i = len(b)
+		}
+		for _, c := range b[:i] {
+			if c == '\n' {
+				mappings.WriteString(";")
+				col, prevCol, segments = 0, 0, 0
+				continue
+			}
+			col++
+		}
+		out = append(out, b[:i]...)
+		if i == len(b) {
+			break
+		}
+
+		b = b[i+1:]
+		j := bytes.IndexByte(b, markDelim)
+		idx, _ := strconv.Atoi(string(b[:j]))
+		b = b[j+1:]
+
+		mk := m.marks[idx]
+		pos := m.fileSet.Position(mk.pos)
+		if segments > 0 {
+			mappings.WriteString(",")
+		}
+		segments++
+		// fields are relative to the previous segment; the source index is always 0
+		writeVLQ(mappings, col-prevCol)
+		writeVLQ(mappings, 0)
+		writeVLQ(mappings, pos.Line-1-prevLine)
+		writeVLQ(mappings, pos.Column-1-prevSrcCol)
+		prevCol, prevLine, prevSrcCol = col, pos.Line-1, pos.Column-1
+		if mk.name != "" {
+			n, ok := names[mk.name]
+			if !ok {
+				n = len(sm.Names)
+				names[mk.name] = n
+				sm.Names = append(sm.Names, mk.name)
+			}
+			writeVLQ(mappings, n-prevName)
+			prevName = n
+		}
+	}
+
+	sm.Mappings = mappings.String()
+	return out, sm
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes n in the Base64 VLQ encoding of source maps.
+func writeVLQ(sb *strings.Builder, n int) {
+	v := n << 1
+	if n < 0 {
+		v = (-n << 1) | 1
+	}
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		sb.WriteByte(base64Chars[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// readVLQ reads a number in the Base64 VLQ encoding from the start of s,
+// and returns it with the rest of s.
+func readVLQ(s string) (int, string, error) {
+	v, shift := 0, 0
+	for {
+		if s == "" {
+			return 0, "", errors.New("invalid source map mappings")
+		}
+		digit := strings.IndexByte(base64Chars, s[0])
+		if digit < 0 {
+			return 0, "", errors.New("invalid source map mappings")
+		}
+		s = s[1:]
+		v |= (digit & 0x1f) << shift
+		shift += 5
+		if digit&0x20 == 0 {
+			break
+		}
+	}
+	if v&1 == 1 {
+		return -(v >> 1), s, nil
+	}
+	return v >> 1, s, nil
+}
@@ -0,0 +1,108 @@
+package minify
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MinifyPackageResult_sourceMaps(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func greet(name string) {
+	message := "Hello, " + name
+	fmt.Println(message)
+}
+
+func main() {
+	greet("gopher")
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := New(Options{RenameLocals: true, LineDirectives: true, SourceMaps: true}).MinifyPackageResult(fset, []*ast.File{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, sm := res.Files[0], res.SourceMaps[0]
+	assert.NotContains(t, string(got), "\x00")
+	assert.Equal(t, 3, sm.Version)
+	assert.Equal(t, []string{"main.go"}, sm.Sources)
+	assert.Equal(t, []string{"name", "message"}, sm.Names)
+
+	origLines := strings.Split(src, "\n")
+	genLines := strings.Split(string(got), "\n")
+	mappings, err := sm.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, mappings)
+	for _, mp := range mappings {
+		gen := genLines[mp.Line][mp.Column:]
+		orig := origLines[mp.SourceLine][mp.SourceColumn:]
+		if mp.Name != "" {
+			// renamed identifiers map to their original name
+			assert.True(t, strings.HasPrefix(orig, mp.Name), orig)
+			continue
+		}
+		assert.Equal(t, leadingToken(orig), leadingToken(gen))
+	}
+}
+
+// leadingToken returns the identifier or keyword at the start of s, or its first byte.
+func leadingToken(s string) string {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	switch i {
+	case -1:
+		return s
+	case 0:
+		return s[:1]
+	}
+	return s[:i]
+}
+
+func Test_SourceMapDecode(t *testing.T) {
+	tests := []struct {
+		mappings string
+		want     []Mapping
+		wantErr  bool
+	}{
+		{
+			mappings: "AAAA,IAAIA;;CAAD",
+			want: []Mapping{
+				{Line: 0, Column: 0, SourceLine: 0, SourceColumn: 0},
+				{Line: 0, Column: 4, SourceLine: 0, SourceColumn: 4, Name: "name"},
+				{Line: 2, Column: 1, SourceLine: 0, SourceColumn: 3},
+			},
+		},
+		{mappings: "AA", wantErr: true},
+		{mappings: "AAAA,I", wantErr: true},
+		{mappings: "AAAA!", wantErr: true},
+		{mappings: "AAAAC", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mappings, func(t *testing.T) {
+			sm := &SourceMap{Version: 3, Names: []string{"name"}, Mappings: tt.mappings}
+			got, err := sm.Decode()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,9 +1,8 @@
-package mingo
+package minify
 
 import (
 	"fmt"
 	"go/ast"
-	"go/token"
 	"strings"
 )
 
@@ -50,7 +49,7 @@ func (m *mingo) stringifyStmt(stmt ast.Stmt) string {
 	case *ast.TypeSwitchStmt:
 		return m.stringifyTypeSwitchStmt(x)
 	default:
-		panic(fmt.Sprintf("unhandled stmt: %#v", x))
+		return m.unsupported(stmt)
 	}
 }
 
@@ -59,6 +58,7 @@ func (m *mingo) stringifyBlockStmt(stmt *ast.BlockStmt) string {
 
 	sb.WriteString("{")
 	for i, child := range stmt.List {
+		sb.WriteString(m.stringifyPos(child.Pos()))
 		sb.WriteString(m.stringifyStmt(child))
 
 		if _, ok := child.(*ast.DeclStmt); !ok {
@@ -98,11 +98,7 @@ func (m *mingo) stringifyAssignStmt(stmt *ast.AssignStmt) string {
 		}
 		sb.WriteString(m.stringifyExpr(expr))
 	}
-	if stmt.Tok == token.DEFINE {
-		sb.WriteString(":=")
-	} else {
-		sb.WriteString("=")
-	}
+	sb.WriteString(stmt.Tok.String())
 	for i, expr := range stmt.Rhs {
 		if i > 0 {
 			sb.WriteString(",")
@@ -188,7 +184,7 @@ func (m *mingo) stringifyGoStmt(stmt *ast.GoStmt) string {
 
 func (m *mingo) stringifyLabeledStmt(stmt *ast.LabeledStmt) string {
 	sb := new(strings.Builder)
-	sb.WriteString(fmt.Sprintf("%s:", stmt.Label.Name))
+	sb.WriteString(fmt.Sprintf("%s:", m.stringifyIdent(stmt.Label)))
 	sb.WriteString(m.stringifyStmt(stmt.Stmt))
 
 	return sb.String()
@@ -274,7 +270,7 @@ func (m *mingo) stringifyBranchStmt(stmt *ast.BranchStmt) string {
 	sb.WriteString(stmt.Tok.String())
 	if stmt.Label != nil {
 		sb.WriteString(" ")
-		sb.WriteString(stmt.Label.Name)
+		sb.WriteString(m.stringifyIdent(stmt.Label))
 	}
 	return sb.String()
 }
@@ -316,6 +312,7 @@ func (m *mingo) stringifyCaseCaluse(stmt *ast.CaseClause) string {
 		sb.WriteString("default:")
 	}
 	for i, child := range stmt.Body {
+		sb.WriteString(m.stringifyPos(child.Pos()))
 		sb.WriteString(m.stringifyStmt(child))
 		if i < len(stmt.Body)-1 {
 			sb.WriteString(";")
@@ -336,6 +333,7 @@ func (m *mingo) stringifyCommClause(stmt *ast.CommClause) string {
 		sb.WriteString("default:")
 	}
 	for _, stmt := range stmt.Body {
+		sb.WriteString(m.stringifyPos(stmt.Pos()))
 		sb.WriteString(m.stringifyStmt(stmt))
 		sb.WriteString(";")
 	}
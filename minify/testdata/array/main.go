@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+const N = 3
+
+type Digest [32]byte
+
+type Matrix [N][2 * N]int
+
+type Small interface {
+	~[4]byte | [N]int
+}
+
+func first[T Small](v T) T {
+	return v
+}
+
+func main() {
+	var buf [4]byte
+	names := [...]string{"a", "b", "c"}
+	indexed := [...]int{5: 1, 2}
+	grid := Matrix{}
+	ptr := &[2]bool{true, false}
+	conv := [4]byte(buf[:])
+	slice := []int{1, 2, 3}
+
+	fmt.Println(buf, len(names), len(indexed), grid, ptr, conv, slice, Digest{}, first([N]int{}))
+}
@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+func main() {
+	x := 10
+	var y int
+	y = 3
+	a, b := 1, 2
+	a, b = b, a
+	x += y
+	x -= 1
+	x *= 2
+	x /= 3
+	x %= 7
+	x &= 0xff
+	x |= 1
+	x ^= 2
+	x <<= 3
+	x >>= 1
+	x &^= 4
+	fmt.Println(x, y, a, b)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+/*
+#cgo CFLAGS: -O2
+
+#include <stdlib.h>
+
+static int add(int a, int b) {
+	return a + b;
+}
+
+void callback(int);
+*/
+import "C"
+
+//export callback
+func callback(n C.int) {
+	fmt.Println(n)
+}
+
+func main() {
+	s := C.CString("hello")
+	defer C.free(unsafe.Pointer(s))
+	fmt.Println(C.add(1, 2))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	// #include <stdio.h>
+	//
+	// #define GREETING \
+	//     "hello"
+	//
+	// static void greet() {
+	//     /* print the greeting */
+	//     puts(GREETING);
+	// }
+	"C"
+)
+
+func main() {
+	C.greet()
+	fmt.Println()
+}
@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+type Producer interface {
+	Jobs() <-chan int
+	Results(chan<- string)
+}
+
+type pool struct {
+	jobs    chan int
+	results chan<- string
+	nested  chan<- <-chan int
+	inner   chan (<-chan int)
+	sends   <-chan chan<- int
+}
+
+func (p *pool) Jobs() <-chan int {
+	return p.jobs
+}
+
+func (p *pool) Results(results chan<- string) {
+	p.results = results
+}
+
+func worker(jobs <-chan int, results chan<- string) {
+	for job := range jobs {
+		results <- fmt.Sprint(job)
+	}
+	close(results)
+}
+
+func main() {
+	jobs := make(chan int, 1)
+	results := make(chan string, 1)
+	var p Producer = &pool{jobs: jobs}
+	p.Results(results)
+
+	jobs <- 1
+	close(jobs)
+	worker(p.Jobs(), results)
+	fmt.Println(<-results, (<-chan int)(jobs) != nil)
+}
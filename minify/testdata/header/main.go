@@ -0,0 +1,15 @@
+//go:build linux
+
+/*
+Copyright 2024 The Authors.
+
+Licensed under the Apache License, Version 2.0.
+*/
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main is not kept.
+package main
+
+// SPDX-License-Identifier: not kept after the package clause
+func main() {}
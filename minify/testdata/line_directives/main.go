@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+//go:noinline
+func div(a, b int) int {
+	switch {
+	case b < 0:
+		fmt.Println("negative"); return -a / -b
+	}
+	f := func() int {
+		return a / b
+	}
+	return f()
+}
+
+func main() {
+	fmt.Println(div(4, 2))
+}
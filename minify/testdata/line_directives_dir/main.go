@@ -0,0 +1,7 @@
+package main
+
+func main() {
+	var s []int
+
+	println(s[3])
+}
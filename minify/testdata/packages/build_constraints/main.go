@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+func greeting() string {
+	return "hello from " + platformName()
+}
+
+func main() {
+	fmt.Println(greeting())
+}
@@ -0,0 +1,8 @@
+//go:build linux
+
+package main
+
+func platformName() string {
+	name := "linux"
+	return name
+}
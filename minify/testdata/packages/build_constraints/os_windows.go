@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+func platformName() string {
+	name := "windows"
+	return name
+}
@@ -0,0 +1,13 @@
+//go:build ignore
+
+package main
+
+import "fmt"
+
+func greeting() string {
+	return "hello from a tool"
+}
+
+func main() {
+	fmt.Println(greeting())
+}
@@ -0,0 +1,52 @@
+//go:debug panicnil=1
+
+package main
+
+import (
+	"embed"
+	_ "unsafe"
+)
+
+//go:embed *.txt
+var files embed.FS
+
+var (
+	// version is set at build time.
+	version = "dev"
+
+	//go:embed version.txt
+	versionFile string
+)
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+// add is not inlined, so that it shows up in profiles.
+//
+//go:noinline
+func add(first, second int) int {
+	return first + second
+}
+
+//go:nosplit
+
+func sub(first, second int) int {
+	//go:noinline is ignored in function bodies
+	return first - second
+}
+
+type (
+	point struct{ x, y int }
+
+	//go:notinheap is no longer supported, but kept in place
+	node struct{ next *node }
+)
+
+func main() {
+	_ = add(1, sub(2, 3)) + int(nanotime())
+	_, _, _ = files, version, versionFile
+	_, _ = point{}, node{}
+}
+
+//go:linkname fastrand runtime.fastrand
+var fastrand func() uint32
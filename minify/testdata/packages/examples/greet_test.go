@@ -0,0 +1,26 @@
+package greet
+
+import "fmt"
+
+func ExampleHello() {
+	// greet a friend
+	Hello("gopher")
+	Hello("world")
+	// Output:
+	// Hello, gopher!
+	// Hello, world!
+}
+
+func ExampleHello_unordered() {
+	for _, name := range []string{"a", "b"} {
+		Hello(name)
+	}
+	// Unordered output:
+	// Hello, b!
+	// Hello, a!
+}
+
+func ExampleHello_noOutput() {
+	// Hello prints to standard output
+	fmt.Println()
+}
@@ -0,0 +1,47 @@
+package main
+
+import "fmt"
+
+const defaultGreeting = "hello"
+
+var greetingCount int
+
+type counter struct {
+	total int
+}
+
+func (c *counter) increment() {
+	c.total++
+}
+
+type printedConfig struct {
+	verbose bool
+}
+
+type stringer interface {
+	describe() string
+}
+
+type greeter struct {
+	counter
+	name string
+}
+
+func (g greeter) describe() string {
+	return g.name
+}
+
+func init() {
+	greetingCount = 1
+}
+
+func main() {
+	g := greeter{name: defaultGreeting}
+	g.increment()
+	g.counter.increment()
+
+	var s stringer = g
+	fmt.Println(s.describe(), g.total, greetingCount)
+	fmt.Printf("%+v\n", printedConfig{verbose: true})
+	fmt.Println(buildMessage(g.name))
+}
@@ -0,0 +1,26 @@
+package main
+
+type Exported struct {
+	hidden int
+}
+
+type messageState struct {
+	visits int
+	Label  string
+}
+
+func (s *messageState) visit() int {
+	s.visits++
+	return s.visits
+}
+
+func buildMessage(name string) string {
+	state := &messageState{Label: name}
+	state.visit()
+	if state.visit() > 1 {
+		return state.Label + suffix
+	}
+	return name
+}
+
+var suffix = "!"
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+type counter struct {
+	total int
+}
+
+func (c *counter) add(value int) {
+	c.total += value
+}
+
+func main() {
+	var items counter
+	for index := 0; index < 3; index++ {
+		items.add(index)
+	}
+	fmt.Println(format(items.total))
+}
@@ -0,0 +1,8 @@
+package main
+
+import "strconv"
+
+func format(number int) string {
+	result := strconv.Itoa(number)
+	return result
+}
@@ -0,0 +1,30 @@
+package counter
+
+// Counter counts things.
+type Counter struct {
+	count int
+	step  int
+}
+
+// New returns a Counter that counts by step.
+func New(step int) *Counter {
+	return &Counter{step: clamp(step)}
+}
+
+// Add counts once.
+func (c *Counter) Add() {
+	c.count = next(c.count, c.step)
+}
+
+func next(count, step int) int {
+	return count + step
+}
+
+func clamp(step int) int {
+	if step < 1 {
+		return defaultStep
+	}
+	return step
+}
+
+const defaultStep = 1
@@ -0,0 +1,17 @@
+package counter
+
+import "testing"
+
+func TestClamp(t *testing.T) {
+	if got := clamp(0); got != defaultStep {
+		t.Errorf("clamp(0) = %d", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c := New(2)
+	c.Add()
+	if c.count != 2 {
+		t.Errorf("count = %d", c.count)
+	}
+}
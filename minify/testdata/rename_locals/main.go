@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+type Request struct {
+	Name string
+}
+
+var a = "global"
+
+func handle(requestContext string, request *Request) (result string, err error) {
+	prefix := fmt.Sprintf("%s:%s", requestContext, a)
+	for index, character := range request.Name {
+		prefix += fmt.Sprint(index, character)
+	}
+
+	if request.Name == "" {
+		err = fmt.Errorf("empty")
+		return
+	}
+
+	result = prefix
+	return
+}
+
+func describe(value any) string {
+	switch concrete := value.(type) {
+	case int:
+		return fmt.Sprint(concrete + 1)
+	case string:
+		return concrete
+	}
+
+	counter := 0
+	callback := func(increment int) {
+		counter += increment
+	}
+	callback(1)
+
+outer:
+	for {
+		for {
+			break outer
+		}
+	}
+
+	return fmt.Sprint(counter, Request{Name: "name"})
+}
+
+func main() {
+	fmt.Println(handle("ctx", &Request{Name: "mingo"}))
+	fmt.Println(describe(1))
+}
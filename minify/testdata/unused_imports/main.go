@@ -0,0 +1,20 @@
+package main
+
+// This file does not compile, as os, math and errors are not used:
+// RemoveUnusedImports cleans up such input.
+
+import (
+	_ "embed"
+	"fmt"
+	m "math"
+	"os"
+	. "strings"
+
+	"example.com/yaml.v3"
+)
+
+import "errors"
+
+func main() {
+	fmt.Println(ToUpper("hello"), yaml.Marshal)
+}
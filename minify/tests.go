@@ -0,0 +1,82 @@
+package minify
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// TestsMode controls how test files, whose names end in _test.go, are minified.
+type TestsMode int
+
+const (
+	// TestsMinify minifies test files like any other file.
+	TestsMinify TestsMode = iota
+
+	// TestsSkip leaves test files unchanged. In MinifyPackage, test files are
+	// still type-checked with the package and the identifiers they refer to
+	// are not renamed, but their source is returned formatted by go/format.
+	TestsSkip
+
+	// TestsPreserveExamples minifies test files, but keeps the output comments
+	// of example functions so that go test still checks their output.
+	TestsPreserveExamples
+)
+
+var testsModes = []string{"minify", "skip", "preserve-examples"}
+
+func (t TestsMode) String() string {
+	if t < 0 || int(t) >= len(testsModes) {
+		return fmt.Sprintf("TestsMode(%d)", int(t))
+	}
+	return testsModes[t]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t TestsMode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// It accepts "minify", "skip" and "preserve-examples".
+func (t *TestsMode) UnmarshalText(text []byte) error {
+	for i, s := range testsModes {
+		if string(text) == s {
+			*t = TestsMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid tests mode %q: must be one of %s", text, strings.Join(testsModes, ", "))
+}
+
+// isTestFile reports whether file is a test file.
+func isTestFile(fset *token.FileSet, file *ast.File) bool {
+	return strings.HasSuffix(fset.Position(file.Pos()).Filename, "_test.go")
+}
+
+var outputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// exampleOutput returns the output comment of an example function,
+// which is the last comment in its body, the same way as go test finds it.
+func exampleOutput(fn *ast.FuncDecl, comments []*ast.CommentGroup) *ast.CommentGroup {
+	if fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Example") || fn.Body == nil {
+		return nil
+	}
+
+	var last *ast.CommentGroup
+	for _, cg := range comments {
+		if cg.Pos() < fn.Body.Lbrace {
+			continue
+		}
+		if cg.End() > fn.Body.Rbrace {
+			break
+		}
+		last = cg
+	}
+	if last == nil || !outputPrefix.MatchString(last.Text()) {
+		return nil
+	}
+	return last
+}
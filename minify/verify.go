@@ -0,0 +1,286 @@
+package minify
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// VerifyError is returned when Options.Verify is set and the minified source
+// does not have the same exported API as the original source.
+type VerifyError struct {
+	// Diffs describes each difference in the exported API.
+	Diffs []string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("minified source differs from the original:\n\t%s", strings.Join(e.Diffs, "\n\t"))
+}
+
+// verify type-checks the original files and their minified sources and
+// compares the exported API of both packages.
+func verify(fset *token.FileSet, files []*ast.File, out [][]byte) error {
+	pkg, _, err := typeCheck(fset, files)
+
+	minFset := token.NewFileSet()
+	minFiles := make([]*ast.File, len(files))
+	for i, file := range files {
+		f, err := parser.ParseFile(minFset, fset.Position(file.Pos()).Filename, out[i], 0)
+		if err != nil {
+			return &VerifyError{Diffs: []string{err.Error()}}
+		}
+		minFiles[i] = f
+	}
+	minPkg, _, minErr := typeCheck(minFset, minFiles)
+
+	if err == nil && minErr != nil {
+		return &VerifyError{Diffs: []string{fmt.Sprintf("does not type-check: %s", minErr)}}
+	}
+
+	want, got := exportedAPI(pkg), exportedAPI(minPkg)
+
+	var names []string
+	for name := range want {
+		names = append(names, name)
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		w, inWant := want[name]
+		g, inGot := got[name]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("%s: missing", name))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", name, g))
+		case w != g:
+			diffs = append(diffs, fmt.Sprintf("%s: %s, want %s", name, g, w))
+		}
+	}
+	if len(diffs) > 0 {
+		return &VerifyError{Diffs: diffs}
+	}
+
+	return nil
+}
+
+// exportedAPI describes each exported package-level object of pkg.
+// The descriptions do not depend on parameter names or on the names of
+// unexported identifiers, which the renaming passes may change.
+func exportedAPI(pkg *types.Package) map[string]string {
+	api := map[string]string{}
+	scope := pkg.Scope()
+
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+
+		w := &apiWriter{pkg: pkg, seen: map[*types.TypeName]bool{}}
+		switch obj := obj.(type) {
+		case *types.Const:
+			w.printf("const %s = %s", w.typ(obj.Type()), obj.Val().ExactString())
+		case *types.Var:
+			w.printf("var %s", w.typ(obj.Type()))
+		case *types.Func:
+			w.printf("func%s", w.typ(obj.Type()))
+		case *types.TypeName:
+			if obj.IsAlias() {
+				w.printf("type = %s", w.typ(obj.Type()))
+			} else {
+				w.printf("type %s", w.named(obj.Type()))
+			}
+		}
+		api[name] = w.String()
+	}
+
+	return api
+}
+
+type apiWriter struct {
+	strings.Builder
+
+	pkg  *types.Package
+	seen map[*types.TypeName]bool
+}
+
+func (w *apiWriter) printf(format string, args ...any) {
+	fmt.Fprintf(w, format, args...)
+}
+
+// named describes the underlying type and exported method set of a named type.
+func (w *apiWriter) named(t types.Type) string {
+	sb := new(strings.Builder)
+
+	if n, ok := t.(*types.Named); ok && n.TypeParams() != nil {
+		sb.WriteString("[")
+		for i := 0; i < n.TypeParams().Len(); i++ {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(w.typ(n.TypeParams().At(i).Constraint()))
+		}
+		sb.WriteString("]")
+	}
+
+	sb.WriteString(w.typ(t.Underlying()))
+
+	for _, recv := range []types.Type{t, types.NewPointer(t)} {
+		mset := types.NewMethodSet(recv)
+		for i := 0; i < mset.Len(); i++ {
+			if m := mset.At(i).Obj(); m.Exported() {
+				fmt.Fprintf(sb, "; %s.%s%s", w.typ(recv), m.Name(), w.typ(m.Type()))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// typ describes t. Named types of other packages and exported named types of
+// the package are described by name, unexported ones by their structure.
+func (w *apiWriter) typ(t types.Type) string {
+	switch x := t.(type) {
+	case *types.Named:
+		obj := x.Obj()
+		sb := new(strings.Builder)
+		switch {
+		case obj.Pkg() == nil:
+			sb.WriteString(obj.Name())
+		case obj.Pkg() != w.pkg:
+			fmt.Fprintf(sb, "%s.%s", obj.Pkg().Path(), obj.Name())
+		case obj.Exported():
+			sb.WriteString(obj.Name())
+		case w.seen[x.Origin().Obj()]:
+			sb.WriteString("unexported")
+		default:
+			w.seen[x.Origin().Obj()] = true
+			fmt.Fprintf(sb, "unexported(%s)", w.named(x))
+			delete(w.seen, x.Origin().Obj())
+		}
+		if args := x.TypeArgs(); args != nil {
+			sb.WriteString("[")
+			for i := 0; i < args.Len(); i++ {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString(w.typ(args.At(i)))
+			}
+			sb.WriteString("]")
+		}
+		return sb.String()
+	case *types.Alias:
+		return w.typ(types.Unalias(x))
+	case *types.Basic:
+		return x.Name()
+	case *types.Pointer:
+		return "*" + w.typ(x.Elem())
+	case *types.Slice:
+		return "[]" + w.typ(x.Elem())
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", x.Len(), w.typ(x.Elem()))
+	case *types.Map:
+		return fmt.Sprintf("map[%s]%s", w.typ(x.Key()), w.typ(x.Elem()))
+	case *types.Chan:
+		switch x.Dir() {
+		case types.SendOnly:
+			return "chan<- " + w.typ(x.Elem())
+		case types.RecvOnly:
+			return "<-chan " + w.typ(x.Elem())
+		}
+		return "chan " + w.typ(x.Elem())
+	case *types.Struct:
+		sb := new(strings.Builder)
+		sb.WriteString("struct{")
+		for i := 0; i < x.NumFields(); i++ {
+			if i > 0 {
+				sb.WriteString("; ")
+			}
+			f := x.Field(i)
+			switch {
+			case f.Embedded():
+				sb.WriteString("embedded ")
+			case f.Exported():
+				sb.WriteString(f.Name() + " ")
+			default:
+				sb.WriteString("_ ")
+			}
+			sb.WriteString(w.typ(f.Type()))
+			if tag := x.Tag(i); tag != "" {
+				fmt.Fprintf(sb, " %q", tag)
+			}
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case *types.Interface:
+		sb := new(strings.Builder)
+		sb.WriteString("interface{")
+		for i := 0; i < x.NumEmbeddeds(); i++ {
+			sb.WriteString(w.typ(x.EmbeddedType(i)) + "; ")
+		}
+		for i := 0; i < x.NumExplicitMethods(); i++ {
+			m := x.ExplicitMethod(i)
+			sb.WriteString(m.Name() + w.typ(m.Type()) + "; ")
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case *types.Union:
+		terms := make([]string, x.Len())
+		for i := range terms {
+			term := x.Term(i)
+			terms[i] = w.typ(term.Type())
+			if term.Tilde() {
+				terms[i] = "~" + terms[i]
+			}
+		}
+		return strings.Join(terms, "|")
+	case *types.Signature:
+		sb := new(strings.Builder)
+		if tparams := x.TypeParams(); tparams != nil {
+			sb.WriteString("[")
+			for i := 0; i < tparams.Len(); i++ {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString(w.typ(tparams.At(i).Constraint()))
+			}
+			sb.WriteString("]")
+		}
+		sb.WriteString("(")
+		for i := 0; i < x.Params().Len(); i++ {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			if x.Variadic() && i == x.Params().Len()-1 {
+				sb.WriteString("...")
+			}
+			sb.WriteString(w.typ(x.Params().At(i).Type()))
+		}
+		sb.WriteString(")")
+		if x.Results().Len() > 0 {
+			sb.WriteString(w.typ(x.Results()))
+		}
+		return sb.String()
+	case *types.Tuple:
+		elems := make([]string, x.Len())
+		for i := range elems {
+			elems[i] = w.typ(x.At(i).Type())
+		}
+		return "(" + strings.Join(elems, ",") + ")"
+	case *types.TypeParam:
+		return fmt.Sprintf("$%d", x.Index())
+	}
+
+	return t.String()
+}